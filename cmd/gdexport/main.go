@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	intCLI "github.com/erikh/gdocs-export/pkg/cli"
 	"github.com/erikh/gdocs-export/pkg/converters"
@@ -17,6 +19,80 @@ import (
 	"google.golang.org/api/docs/v1"
 )
 
+// optionFlags are the flags for converters.Options, shared by every command
+// that converts documents.
+var optionFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "anchors",
+		Usage: "Emit explicit heading anchors in formats that do not need them (e.g. markdown)",
+	},
+	&cli.BoolFlag{
+		Name:  "toc",
+		Usage: "Insert a table of contents at the top of the document if it does not have one",
+	},
+	&cli.BoolFlag{
+		Name:  "table-header",
+		Usage: "Treat the first row of tables as a header row",
+	},
+	&cli.StringSliceFlag{
+		Name:  "code-fonts",
+		Usage: "Fonts that mark text as code, comma separated (default: " + strings.Join(converters.DefaultCodeFonts, ", ") + ")",
+	},
+	&cli.BoolFlag{
+		Name:  "figures",
+		Usage: "Turn images followed by a caption (in italics, or starting with Figure or Caption) into figures",
+	},
+	&cli.BoolFlag{
+		Name:  "blockquotes",
+		Usage: "Turn indented paragraphs into block quotes",
+	},
+	&cli.Float64Flag{
+		Name:  "quote-indent",
+		Usage: "The left indent in points that makes a paragraph a block quote (default 36, one indent step in docs)",
+	},
+	&cli.StringFlag{
+		Name:  "admonitions",
+		Usage: "Turn callouts (single cell tables that are shaded or start with an emoji like ℹ️ or ⚠️) into admonitions: github, mkdocs or docusaurus",
+	},
+	&cli.StringFlag{
+		Name:  "titles",
+		Usage: "How to output the title and subtitle styles: plain, heading (title as the top heading, subtitle as a subtitle paragraph), or front-matter",
+		Value: "plain",
+	},
+	&cli.IntFlag{
+		Name:  "heading-offset",
+		Usage: "Shift every heading down this many levels, up to level 6",
+	},
+	&cli.BoolFlag{
+		Name:  "standalone",
+		Usage: "Wrap latex output in a preamble so it compiles on its own",
+	},
+	&cli.BoolFlag{
+		Name:  "headers-footers",
+		Usage: "Include the document's headers and footers at the start and end of the output",
+	},
+	&cli.StringFlag{
+		Name:  "suggestions",
+		Usage: "What to do with suggested changes: accept, reject, or mark them as insertions and deletions (default: leave them in the text)",
+	},
+	&cli.BoolFlag{
+		Name:  "warn",
+		Usage: "Report document elements that cannot be converted on stderr",
+	},
+	&cli.StringFlag{
+		Name:  "page-breaks",
+		Usage: "What to do with page and section breaks: ignore, div (break the page when printed), or split (one file per page; requires --output)",
+		Value: "ignore",
+	},
+}
+
+// outputFlag is the flag for where converted documents are written.
+var outputFlag = &cli.StringFlag{
+	Name:    "output",
+	Aliases: []string{"o"},
+	Usage:   "Write the output to this file instead of stdout. Split pages are numbered: output-1.md, output-2.md, ...",
+}
+
 func main() {
 	app := cli.NewApp()
 
@@ -43,7 +119,7 @@ func main() {
 			Usage:     "Download the document and (optionally) convert it",
 			ArgsUsage: "[gdocs url]",
 			Aliases:   []string{"f", "download"},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				&cli.StringFlag{
 					Name:    "assets-dir",
 					Aliases: []string{"a"},
@@ -60,7 +136,8 @@ func main() {
 					Aliases: []string{"c"},
 					Usage:   "Convert to various formats; -c help for more",
 				},
-			},
+				outputFlag,
+			}, optionFlags...),
 			Action: fetch,
 		},
 		{
@@ -68,13 +145,14 @@ func main() {
 			Usage:     "Convert an already-downloaded document from JSON",
 			ArgsUsage: "[format] [filename]",
 			Aliases:   []string{"c", "transform"},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				&cli.StringFlag{
 					Name:    "assets-dir",
 					Aliases: []string{"a"},
 					Usage:   "Where downloaded assets are kept (must exist already, with a manifest.json present)",
 				},
-			},
+				outputFlag,
+			}, optionFlags...),
 			Action: convert,
 		},
 	}
@@ -86,10 +164,89 @@ func main() {
 
 func convertFormatHelp() {
 	fmt.Println("Formats supported:")
-	fmt.Println("md, html")
+	fmt.Println("md, html, adoc, rst, latex, org, mediawiki, confluence")
 	os.Exit(0)
 }
 
+func convertOptions(ctx *cli.Context) (converters.Options, error) {
+	var pageBreaks converters.PageBreakMode
+
+	switch mode := ctx.String("page-breaks"); mode {
+	case "", "ignore":
+		pageBreaks = converters.PageBreaksIgnore
+	case "div":
+		pageBreaks = converters.PageBreaksDiv
+	case "split":
+		pageBreaks = converters.PageBreaksSplit
+	default:
+		return converters.Options{}, fmt.Errorf("invalid --page-breaks %q; must be ignore, div or split", mode)
+	}
+
+	var titles converters.TitleMode
+
+	switch mode := ctx.String("titles"); mode {
+	case "", "plain":
+		titles = converters.TitlesPlain
+	case "heading":
+		titles = converters.TitlesHeading
+	case "front-matter":
+		titles = converters.TitlesFrontMatter
+	default:
+		return converters.Options{}, fmt.Errorf("invalid --titles %q; must be plain, heading or front-matter", mode)
+	}
+
+	var admonitions converters.AdmonitionStyle
+
+	switch style := converters.AdmonitionStyle(ctx.String("admonitions")); style {
+	case converters.AdmonitionsNone, converters.AdmonitionsGitHub, converters.AdmonitionsMkDocs, converters.AdmonitionsDocusaurus:
+		admonitions = style
+	default:
+		return converters.Options{}, fmt.Errorf("invalid --admonitions %q; must be github, mkdocs or docusaurus", style)
+	}
+
+	suggestions, err := converters.ParseSuggestionMode(ctx.String("suggestions"))
+	if err != nil {
+		return converters.Options{}, err
+	}
+
+	opts := converters.Options{
+		Anchors:        ctx.Bool("anchors"),
+		TOC:            ctx.Bool("toc"),
+		TableHeader:    ctx.Bool("table-header"),
+		CodeFonts:      splitList(ctx.StringSlice("code-fonts")),
+		PageBreaks:     pageBreaks,
+		Figures:        ctx.Bool("figures"),
+		Suggestions:    suggestions,
+		HeadersFooters: ctx.Bool("headers-footers"),
+		Standalone:     ctx.Bool("standalone"),
+		Blockquotes:    ctx.Bool("blockquotes"),
+		QuoteIndent:    ctx.Float64("quote-indent"),
+		Admonitions:    admonitions,
+		Titles:         titles,
+		HeadingOffset:  ctx.Int("heading-offset"),
+	}
+
+	if ctx.Bool("warn") {
+		opts.Warnings = os.Stderr
+	}
+
+	return opts, nil
+}
+
+// splitList flattens comma separated flag values, which the cli does not split.
+func splitList(values []string) []string {
+	res := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+
+	return res
+}
+
 func convert(ctx *cli.Context) error {
 	if ctx.Args().Get(0) == "help" {
 		convertFormatHelp()
@@ -124,12 +281,46 @@ func convert(ctx *cli.Context) error {
 		}
 	}
 
-	res, err := converters.Convert(ctx.Args().Get(0), &doc, manifest)
+	opts, err := convertOptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	pages, err := converters.ConvertPages(ctx.Args().Get(0), &doc, manifest, opts)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(res)
+	return writeOutput(ctx, pages)
+}
+
+// writeOutput prints the pages of a document, or writes them to the file
+// named by --output. More than one page is written to numbered files.
+func writeOutput(ctx *cli.Context, pages []string) error {
+	out := ctx.String("output")
+
+	if out == "" {
+		if len(pages) > 1 {
+			return errors.New("--page-breaks split needs --output to write the pages to")
+		}
+
+		fmt.Println(pages[0])
+		return nil
+	}
+
+	if len(pages) == 1 {
+		return ioutil.WriteFile(out, []byte(pages[0]+"\n"), 0644)
+	}
+
+	ext := filepath.Ext(out)
+
+	for i, page := range pages {
+		filename := fmt.Sprintf("%s-%d%s", strings.TrimSuffix(out, ext), i+1, ext)
+		if err := ioutil.WriteFile(filename, []byte(page+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -138,6 +329,11 @@ func fetch(ctx *cli.Context) error {
 		convertFormatHelp()
 	}
 
+	opts, err := convertOptions(ctx)
+	if err != nil {
+		return err
+	}
+
 	if ctx.Args().Len() != 1 {
 		fmt.Fprintln(os.Stderr, "Please provide a google docs url to this command.")
 		os.Exit(1)
@@ -152,14 +348,9 @@ func fetch(ctx *cli.Context) error {
 
 	client := oauth2.GetClient()
 
-	srv, err := docs.New(client)
+	doc, err := util.DownloadDoc(client, docID, opts.Suggestions.ViewMode())
 	if err != nil {
-		return fmt.Errorf("Unable to retrieve Docs client: %v", err)
-	}
-
-	doc, err := srv.Documents.Get(docID).Do()
-	if err != nil {
-		return fmt.Errorf("Unable to retrieve data from document: %v", err)
+		return err
 	}
 
 	var manifest downloader.Manifest
@@ -181,17 +372,15 @@ func fetch(ctx *cli.Context) error {
 			return fmt.Errorf("Unable to marshal json: %v", err)
 		}
 
-		fmt.Println(string(content))
-	} else {
-		res, err := converters.Convert(ctx.String("convert"), doc, manifest)
-		if err != nil {
-			return err
-		}
+		return writeOutput(ctx, []string{string(content)})
+	}
 
-		fmt.Println(res)
+	pages, err := converters.ConvertPages(ctx.String("convert"), doc, manifest, opts)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return writeOutput(ctx, pages)
 }
 
 func importCredentials(ctx *cli.Context) error {
@@ -3,7 +3,6 @@ module github.com/erikh/gdocs-export
 go 1.15
 
 require (
-	cloud.google.com/go v0.75.0 // indirect
 	github.com/andreyvit/diff v0.0.0-20170406064948-c7f18ee00883
 	github.com/cpuguy83/go-md2man/v2 v2.0.0 // indirect
 	github.com/labstack/echo/v4 v4.1.17
@@ -12,10 +11,7 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/sergi/go-diff v1.1.0 // indirect
 	github.com/urfave/cli/v2 v2.3.0
-	golang.org/x/oauth2 v0.0.0-20210112200429-01de73cf58bd
-	golang.org/x/sys v0.0.0-20210112091331-59c308dcf3cc // indirect
-	golang.org/x/text v0.3.5 // indirect
-	google.golang.org/api v0.36.0
-	google.golang.org/genproto v0.0.0-20210111234610-22ae2b108f89 // indirect
-	google.golang.org/grpc v1.34.1 // indirect
+	golang.org/x/oauth2 v0.0.0-20220411215720-9780585627b5
+	golang.org/x/text v0.3.7
+	google.golang.org/api v0.80.0
 )
@@ -0,0 +1,470 @@
+package converters
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+var asciidoc = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		LeftPad:  true,
+		Escape:   asciidocEscape,
+	},
+	TokenBold: Tag{
+		Collapse:        true,
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "*" + s },
+		After:           func(s string) string { return s + "*" },
+	},
+	TokenItalic: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "_" + s },
+		After:           func(s string) string { return s + "_" },
+	},
+	TokenStrikethrough: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "[.line-through]#" + s },
+		After:           func(s string) string { return s + "#" },
+	},
+	TokenUnderline: Tag{
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "[.underline]#" + s },
+		After:           func(s string) string { return s + "#" },
+	},
+	// super and subscripts end at the first space
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "^" + strings.Replace(s, " ", "{nbsp}", -1) },
+		After:           func(s string) string { return s + "^" },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "~" + strings.Replace(s, " ", "{nbsp}", -1) },
+		After:           func(s string) string { return s + "~" },
+	},
+	TokenParagraph: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			// the first line may start with markup instead of text
+			text := len(n.Children) > 0 && n.Children[0].Token == TokenPlain
+			return "\n" + asciidocBlockImage(asciidocLineStarts(s, text))
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	// nesting is in the markers, so lists need no markup of their own. Blank
+	// lines between items keep them apart from the surrounding paragraphs.
+	TokenUnorderedList: Tag{},
+	TokenUnorderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return asciidocListItem(strings.Repeat("*", times+1), s) },
+		Before:          func(s string) string { return "\n" + s },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenOrderedList: Tag{},
+	TokenOrderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return asciidocListItem(strings.Repeat(".", times+1), s) },
+		NodeBefore: func(n *Node, s string) string {
+			// the style of the list goes above its first item
+			if style := asciidocListStyle(n); style != "" && listPosition(n) == 1 {
+				return "\n[" + style + "]\n" + s
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenCheckList: Tag{},
+	TokenCheckBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return asciidocListItem(strings.Repeat("*", times+1), s) },
+		NodeBefore: func(n *Node, s string) string {
+			// the checkbox goes after the marker made by Repeat
+			marker := strings.Index(s, " ") + 1
+			if n.Checked {
+				return "\n" + s[:marker] + "[x] " + s[marker:]
+			}
+			return "\n" + s[:marker] + "[ ] " + s[marker:]
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat: func(times int, s string) string {
+			// a single = is the document title, and sections stop at level 5
+			if times > 5 {
+				times = 5
+			}
+			return strings.Repeat("=", times+1) + " " + s
+		},
+		After:  func(s string) string { return s + "\n" },
+		Anchor: func(anchor, s string) string { return "[[" + anchor + "]]\n" + s },
+	},
+	TokenTable: Tag{
+		NodeBefore: func(n *Node, s string) string {
+			if n.Columns > 0 {
+				return fmt.Sprintf("\n[cols=\"%d*\"]\n|===\n", n.Columns) + s
+			}
+			return "\n|===\n" + s
+		},
+		After: func(s string) string { return s + "|===\n" },
+	},
+	// header cells are on one line followed by a blank line, which makes them
+	// the header row. Other cells are on lines of their own. Asciidoc headers
+	// are a single row, so the cells of longer headers are header cells
+	// instead.
+	TokenTableHead: Tag{
+		NodeBefore: func(n *Node, s string) string {
+			s = strings.TrimRight(s, " ") + "\n"
+			if len(n.Children) == 1 {
+				return s + "\n"
+			}
+			return s
+		},
+	},
+	TokenTableHeaderCell: Tag{
+		TrimInside: true,
+		NodeBefore: asciidocCell,
+		After:      func(s string) string { return s + " " },
+	},
+	TokenTableCell: Tag{
+		TrimInside: true,
+		NodeBefore: asciidocCell,
+		After:      func(s string) string { return s + "\n" },
+	},
+	TokenTableRow: Tag{},
+	TokenImage: Tag{
+		MapFile: asciidocImage,
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				if n.Language != "" {
+					return "\n[source," + n.Language + "]\n----\n" + s
+				}
+				return "\n[source]\n----\n" + s
+			}
+			// inline code is finished here; After only closes blocks. A plus
+			// would end the passthrough, so that needs the macro.
+			if strings.Contains(s, "+") {
+				return "`pass:c[" + strings.Replace(s, "]", `\]`, -1) + "]`"
+			}
+			return "`+" + s + "+`"
+		},
+		After: func(s string) string {
+			if strings.HasPrefix(s, "\n[source") {
+				return strings.TrimRight(s, "\n") + "\n----\n"
+			}
+			return s
+		},
+	},
+	TokenLink: Tag{
+		LeftPad: true,
+		Link:    asciidocLink,
+	},
+	TokenTOC: Tag{
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenTOCList: Tag{
+		Before: func(s string) string { return "\n" + s },
+	},
+	TokenTOCEntry: Tag{
+		TrimInside: true,
+		// nested entries get another marker
+		Before: func(s string) string { return "* " + indentLines(s, "*") },
+		After:  func(s string) string { return s + "\n" },
+	},
+	TokenLineBreak: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return " +\n" },
+	},
+	TokenSubtitle: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n[.subtitle]\n" + s },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// asciidoctor drops front matter with the skip-front-matter attribute
+	TokenFrontMatter: Tag{
+		NodeBefore: asciidocTitle,
+	},
+	TokenBlockquote: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n____\n" + s },
+		After:      func(s string) string { return s + "\n____\n" },
+	},
+	TokenAdmonition: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			return "\n[" + strings.ToUpper(n.Admonition) + "]\n====\n" + s
+		},
+		After: func(s string) string { return s + "\n====\n" },
+	},
+	TokenHeader: Tag{
+		After: func(s string) string { return s + "\n'''\n" },
+	},
+	TokenFooter: Tag{
+		Before: func(s string) string { return "\n'''\n" + s },
+	},
+	TokenInsertion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "[.underline]##" + s },
+		After:           func(s string) string { return s + "##" },
+	},
+	TokenDeletion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "[.line-through]##" + s },
+		After:           func(s string) string { return s + "##" },
+	},
+	TokenMath: Tag{
+		NoEscape:   true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "latexmath:[" + strings.Replace(s, "]", `\]`, -1) },
+		After:      func(s string) string { return s + "]" },
+	},
+	TokenMathBlock: Tag{
+		NoEscape: true,
+		Before:   func(s string) string { return "[latexmath]\n++++\n" + s },
+		After:    func(s string) string { return s + "\n++++" },
+	},
+	TokenFloat: Tag{
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			if n.Float != "" {
+				s = strings.Replace(s, "[", "[float="+n.Float+",", 1)
+			}
+			return "\n" + asciidocBlockImage(s)
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenFigure: Tag{
+		Before: asciidocFigure,
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		// block titles can't span lines
+		Before: func(s string) string { return "\n." + strings.Replace(s, "\n", " ", -1) },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "\n'''\n" },
+	},
+	TokenPageBreak: Tag{
+		Before: func(s string) string { return "\n<<<\n" },
+	},
+	TokenFootnoteRef: Tag{
+		NoPadAfter: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf("^<<fn-%d,%d>>^", i, i) },
+	},
+	// footnotes are kept at the end, like they are in docs; asciidoc's own
+	// footnotes would need their text where they are referenced.
+	TokenFootnotes: Tag{
+		Before: func(s string) string { return "\n'''\n" + s },
+	},
+	TokenFootnote: Tag{
+		TrimInside: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf("\n[[fn-%d]]^%d^ %s\n", i, i, s) },
+	},
+}
+
+// asciidocEscape replaces the characters asciidoc reads as formatting with
+// character references. Formatting marks between two letters or digits can't
+// start or end formatting, and are left alone.
+func asciidocEscape(s string) string {
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '*', '_', '`', '#', '+':
+			if i > 0 && i+1 < len(runes) && isWordRune(runes[i-1]) && isWordRune(runes[i+1]) {
+				b.WriteRune(r)
+			} else {
+				fmt.Fprintf(&b, "&#%d;", r)
+			}
+		case '^', '~', '{':
+			fmt.Fprintf(&b, "&#%d;", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// asciidocLineStarts replaces the marks that start block titles, headings,
+// attribute entries and lists at the start of a paragraph, and of the lines
+// after its line breaks, with character references. The start of the
+// paragraph is left alone unless it is text. Other marks at the start of a
+// line are escaped by asciidocEscape.
+func asciidocLineStarts(s string, text bool) string {
+	lines := strings.Split(s, " +\n")
+
+	for i, line := range lines {
+		if (i > 0 || text) && line != "" && strings.ContainsRune(".=:-", rune(line[0])) {
+			lines[i] = fmt.Sprintf("&#%d;", line[0]) + line[1:]
+		}
+	}
+
+	return strings.Join(lines, " +\n")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// asciidocListItem puts the marker before a list item. Items that are a
+// code block get empty text, with the block attached to it by a list
+// continuation.
+func asciidocListItem(marker, s string) string {
+	if strings.HasPrefix(s, "[source") {
+		return marker + " {empty}\n+\n" + s
+	}
+
+	return marker + " " + s
+}
+
+// asciidocListStyles are the styles of ordered lists by their list type.
+var asciidocListStyles = map[string]string{
+	"a": "loweralpha",
+	"A": "upperalpha",
+	"i": "lowerroman",
+	"I": "upperroman",
+}
+
+// asciidocListStyle returns the style of the list the bullet is in. Nested
+// lists are lettered by default, so decimal ones are numbered explicitly.
+func asciidocListStyle(bullet *Node) string {
+	if style, ok := asciidocListStyles[bullet.parent.ListType]; ok {
+		return style
+	}
+
+	if bullet.BulletNesting > 0 {
+		return "arabic"
+	}
+
+	return ""
+}
+
+// asciidocTitle writes the title and subtitle as the document title, which
+// asciidoctor splits into the two at its last colon.
+func asciidocTitle(n *Node, s string) string {
+	title := n.Title
+	if n.Subtitle != "" {
+		title += ": " + n.Subtitle
+	}
+
+	return "= " + asciidocEscape(title) + "\n"
+}
+
+// asciidocAttr quotes a value in a macro's attribute list.
+func asciidocAttr(s string) string {
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + strings.Replace(s, "]", `\]`, -1) + `"`
+}
+
+// asciidocImage returns the inline image macro for an image, with its size,
+// alt text and title.
+func asciidocImage(file downloader.ManifestFile) string {
+	attrs := []string{}
+
+	if file.Description != "" {
+		attrs = append(attrs, "alt="+asciidocAttr(file.Description))
+	}
+
+	attrs = append(attrs, fmt.Sprintf("width=%d", file.Width), fmt.Sprintf("height=%d", file.Height))
+
+	if file.Title != "" {
+		attrs = append(attrs, "title="+asciidocAttr(file.Title))
+	}
+
+	return "image:" + file.Filename + "[" + strings.Join(attrs, ",") + "]"
+}
+
+// asciidocBlockImage turns an inline image that is alone on its line into a
+// block image.
+func asciidocBlockImage(s string) string {
+	if strings.HasPrefix(s, "image:") && strings.HasSuffix(s, "]") && !strings.Contains(s, "\n") &&
+		strings.Count(s, "image:") == 1 {
+		return "image::" + s[len("image:"):]
+	}
+
+	return s
+}
+
+// asciidocFigure moves the caption of a figure above its image, where block
+// titles go.
+func asciidocFigure(s string) string {
+	idx := strings.Index(s, "\n.")
+	if idx < 0 {
+		return "\n" + asciidocBlockImage(strings.TrimSpace(s)) + "\n"
+	}
+
+	return s[idx:] + "\n" + asciidocBlockImage(strings.TrimSpace(s[:idx])) + "\n"
+}
+
+// asciidocCell starts a table cell, with its span. Cells of more than one
+// line are asciidoc cells, so their paragraphs and lists are kept.
+func asciidocCell(n *Node, s string) string {
+	var spec string
+
+	switch {
+	case n.ColSpan > 1 && n.RowSpan > 1:
+		spec = fmt.Sprintf("%d.%d+", n.ColSpan, n.RowSpan)
+	case n.ColSpan > 1:
+		spec = fmt.Sprintf("%d+", n.ColSpan)
+	case n.RowSpan > 1:
+		spec = fmt.Sprintf(".%d+", n.RowSpan)
+	}
+
+	switch {
+	case strings.Contains(s, "\n"):
+		spec += "a"
+	case n.Token == TokenTableHeaderCell && len(n.parent.parent.Children) > 1:
+		spec += "h"
+	}
+
+	return spec + "|" + strings.Replace(s, "|", `\|`, -1)
+}
+
+// asciidocLink links to a url, or cross references an anchor in the
+// document.
+func asciidocLink(href, s string) string {
+	if strings.HasPrefix(href, "#") {
+		return "<<" + href[1:] + "," + s + ">>"
+	}
+
+	s = strings.Replace(s, "]", `\]`, -1)
+
+	if strings.ContainsAny(href, " []") {
+		// the url would end the macro early
+		return "link:++" + href + "++[" + s + "]"
+	}
+
+	return "link:" + href + "[" + s + "]"
+}
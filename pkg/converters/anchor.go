@@ -0,0 +1,111 @@
+package converters
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// slugger generates heading anchors the same way github does, including the
+// numbering of duplicate headings.
+type slugger map[string]int
+
+func (s slugger) slug(text string) string {
+	orig := githubSlug(text)
+	res := orig
+
+	for {
+		if _, ok := s[res]; !ok {
+			break
+		}
+
+		s[orig]++
+		res = orig + "-" + strconv.Itoa(s[orig])
+	}
+
+	s[res] = 0
+	return res
+}
+
+func githubSlug(text string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-', unicode.IsLetter(r), unicode.IsMark(r), unicode.IsNumber(r), unicode.Is(unicode.Pc, r):
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// collectHeadings assigns anchors to every heading in the document ahead of
+// parsing, so links to headings later in the document can be resolved.
+func (p *parser) collectHeadings(content []*docs.StructuralElement) {
+	for _, elem := range content {
+		if elem.Paragraph != nil && elem.Paragraph.ParagraphStyle != nil && p.headingLevel(elem.Paragraph.ParagraphStyle.NamedStyleType) > 0 {
+			var text string
+			for _, pelem := range elem.Paragraph.Elements {
+				if pelem.TextRun != nil {
+					text += strings.Replace(pelem.TextRun.Content, "\u000b", " ", -1)
+				}
+			}
+
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			anchor := p.slugs.slug(text)
+			p.anchors[elem.Paragraph] = anchor
+			p.headings = append(p.headings, heading{
+				level:  p.headingLevel(elem.Paragraph.ParagraphStyle.NamedStyleType),
+				text:   strings.TrimSpace(text),
+				anchor: anchor,
+			})
+			if id := elem.Paragraph.ParagraphStyle.HeadingId; id != "" {
+				p.headingMap[id] = anchor
+			}
+		}
+
+		if elem.TableOfContents != nil {
+			p.hasTOC = true
+		}
+
+		if elem.Table != nil {
+			for _, row := range elem.Table.TableRows {
+				for _, cell := range row.TableCells {
+					p.collectHeadings(cell.Content)
+				}
+			}
+		}
+	}
+}
+
+// linkURL returns the url a link should point at. Links to headings are
+// rewritten to the heading's anchor. Bookmarks are not resolved, as the docs
+// API does not report where they are in the document, so those links are
+// dropped with a warning and their text is kept.
+func (p *parser) linkURL(link *docs.Link, index int64) string {
+	if link.HeadingId != "" {
+		if anchor, ok := p.headingMap[link.HeadingId]; ok {
+			return "#" + anchor
+		}
+	}
+
+	if link.Url == "" && p.opts.Warnings != nil {
+		switch {
+		case link.BookmarkId != "":
+			fmt.Fprintf(p.opts.Warnings, "warning: dropping link to bookmark %s at index %d\n", link.BookmarkId, index)
+		case link.HeadingId != "":
+			fmt.Fprintf(p.opts.Warnings, "warning: dropping link to missing heading %s at index %d\n", link.HeadingId, index)
+		}
+	}
+
+	return link.Url
+}
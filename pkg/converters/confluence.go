@@ -0,0 +1,343 @@
+package converters
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+// confluenceMacros are the macros confluence shows each kind of admonition
+// with.
+var confluenceMacros = map[string]string{
+	"note":      "info",
+	"tip":       "tip",
+	"important": "note",
+	"warning":   "warning",
+	"caution":   "warning",
+}
+
+var confluence = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		Escape:   htmlEscape,
+	},
+	TokenBold: Tag{
+		Collapse:   true,
+		TrimInside: true,
+		Before:     func(s string) string { return "<strong>" + s },
+		After:      func(s string) string { return s + "</strong>" },
+	},
+	TokenItalic: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		TrimInside:      true,
+		Before:          func(s string) string { return "<em>" + s },
+		After:           func(s string) string { return s + "</em>" },
+	},
+	TokenStrikethrough: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		TrimInside:      true,
+		Before:          func(s string) string { return `<span style="text-decoration: line-through;">` + s },
+		After:           func(s string) string { return s + "</span>" },
+	},
+	TokenUnderline: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		TrimInside:      true,
+		Before:          func(s string) string { return "<u>" + s },
+		After:           func(s string) string { return s + "</u>" },
+	},
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<sup>" + s },
+		After:           func(s string) string { return s + "</sup>" },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<sub>" + s },
+		After:           func(s string) string { return s + "</sub>" },
+	},
+	// headings, rules and page breaks are paragraphs in the tree, but can't be
+	// inside one in the storage format.
+	TokenParagraph: Tag{
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before: func(s string) string {
+			// <h covers both headings and rules
+			if strings.HasPrefix(s, "<h") || strings.HasPrefix(s, "<div") {
+				return s
+			}
+			return "<p>" + s
+		},
+		After: func(s string) string {
+			if strings.HasPrefix(s, "<p>") {
+				return s + "</p>\n"
+			}
+			return s + "\n"
+		},
+	},
+	// every list item is a list of its own in the tree, so they are merged
+	// back into one list; confluence ignores the value of list items.
+	TokenUnorderedList: Tag{
+		Merge:      true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<ul>" + s },
+		After:      func(s string) string { return s + "</ul>\n" },
+	},
+	TokenUnorderedBullet: Tag{
+		Before: func(s string) string { return "<li>" + s },
+		After:  func(s string) string { return s + "</li>" },
+	},
+	TokenOrderedList: Tag{
+		Merge:      true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<ol>" + s },
+		After:      func(s string) string { return s + "</ol>\n" },
+	},
+	TokenOrderedBullet: Tag{
+		Before: func(s string) string { return "<li>" + s },
+		After:  func(s string) string { return s + "</li>" },
+	},
+	TokenCheckList: Tag{
+		Merge:      true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<ac:task-list>" + s },
+		After:      func(s string) string { return s + "</ac:task-list>\n" },
+	},
+	TokenCheckBullet: Tag{
+		NodeBefore: func(n *Node, s string) string {
+			status := "incomplete"
+			if n.Checked {
+				status = "complete"
+			}
+			return "<ac:task><ac:task-status>" + status + "</ac:task-status><ac:task-body>" + s
+		},
+		After: func(s string) string { return s + "</ac:task-body></ac:task>" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat: func(times int, s string) string {
+			if times > 6 {
+				times = 6
+			}
+			return fmt.Sprintf("<h%d>%s</h%d>", times, s, times)
+		},
+		Anchor: func(anchor, s string) string {
+			// the anchor macro goes inside the heading generated by Repeat
+			return strings.Replace(s, ">", ">"+confluenceMacro("anchor", confluenceParam("", anchor)), 1)
+		},
+	},
+	TokenTable: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<table><tbody>" + s },
+		After:      func(s string) string { return s + "</tbody></table>\n" },
+	},
+	// confluence has no table head; header cells are set apart on their own
+	TokenTableHead: Tag{},
+	TokenTableHeaderCell: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string { return "<th" + spanAttrs(n.ColSpan, n.RowSpan) + ">" + s },
+		After:      func(s string) string { return s + "</th>" },
+	},
+	TokenTableCell: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string { return "<td" + spanAttrs(n.ColSpan, n.RowSpan) + ">" + s },
+		After:      func(s string) string { return s + "</td>" },
+	},
+	TokenTableRow: Tag{
+		Before: func(s string) string { return "<tr>" + s },
+		After:  func(s string) string { return s + "</tr>" },
+	},
+	TokenImage: Tag{
+		MapFile: confluenceImage,
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				var params string
+				if n.Language != "" {
+					params = confluenceParam("language", n.Language)
+				}
+				body := "<ac:plain-text-body>" + confluenceCDATA(strings.TrimRight(s, "\n")) + "</ac:plain-text-body>"
+				return "\n" + confluenceMacro("code", params+body) + "\n"
+			}
+			return "<code>" + html.EscapeString(s) + "</code>"
+		},
+	},
+	TokenLink: Tag{
+		Link: func(href, s string) string {
+			if strings.HasPrefix(href, "#") {
+				return fmt.Sprintf("<ac:link ac:anchor=%q><ac:link-body>%s</ac:link-body></ac:link>", html.EscapeString(href[1:]), s)
+			}
+			return fmt.Sprintf("<a href=%q>%s</a>", html.EscapeString(href), s)
+		},
+	},
+	// confluence builds its own table of contents from the headings
+	TokenTOC: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return confluenceMacro("toc", "") + "\n" },
+	},
+	TokenTOCList:  Tag{},
+	TokenTOCEntry: Tag{},
+	TokenLineBreak: Tag{
+		Before: func(s string) string { return "<br />\n" },
+	},
+	TokenSubtitle: Tag{
+		NoPadAfter:      true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<p><em>" + s },
+		After:           func(s string) string { return s + "</em></p>\n" },
+	},
+	// the page title is set when the page is created, so the front matter is
+	// kept as page properties.
+	TokenFrontMatter: Tag{
+		NoPadAfter: true,
+		NodeBefore: confluenceProperties,
+	},
+	TokenBlockquote: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<blockquote>\n" + s },
+		After:      func(s string) string { return s + "</blockquote>\n" },
+	},
+	TokenAdmonition: Tag{
+		NoPadAfter: true,
+		NodeBefore: func(n *Node, s string) string {
+			return confluenceMacro(confluenceMacros[n.Admonition], "<ac:rich-text-body>\n"+s+"</ac:rich-text-body>") + "\n"
+		},
+	},
+	TokenHeader: Tag{
+		NoPadAfter: true,
+		After:      func(s string) string { return s + "<hr />\n" },
+	},
+	TokenFooter: Tag{
+		Before: func(s string) string { return "<hr />\n" + s },
+	},
+	TokenInsertion: Tag{
+		RequiresContent: true,
+		Before:          func(s string) string { return "<u>" + s },
+		After:           func(s string) string { return s + "</u>" },
+	},
+	TokenDeletion: Tag{
+		RequiresContent: true,
+		Before:          func(s string) string { return `<span style="text-decoration: line-through;">` + s },
+		After:           func(s string) string { return s + "</span>" },
+	},
+	// confluence has no math of its own; the tex is left for a math macro
+	TokenMath: Tag{
+		Before: func(s string) string { return `\(` + s },
+		After:  func(s string) string { return s + `\)` },
+	},
+	TokenMathBlock: Tag{
+		Before: func(s string) string { return `\[` + s },
+		After:  func(s string) string { return s + `\]` },
+	},
+	TokenFloat: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			if n.Float != "" {
+				s = strings.Replace(s, "<ac:image", fmt.Sprintf("<ac:image ac:align=%q", n.Float), 1)
+			}
+			return "<p>" + s
+		},
+		After: func(s string) string { return s + "</p>\n" },
+	},
+	TokenFigure: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<p>" + s },
+		After:      func(s string) string { return s + "</p>\n" },
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "<br />\n<em>" + s },
+		After:      func(s string) string { return s + "</em>" },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "<hr />" },
+	},
+	TokenPageBreak: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return pageBreakDiv + "\n" },
+	},
+	TokenFootnoteRef: Tag{
+		Footnote: func(i int, s string) string {
+			return fmt.Sprintf(`<sup><ac:link ac:anchor="fn-%d"><ac:plain-text-link-body>%s</ac:plain-text-link-body></ac:link></sup>`, i, confluenceCDATA(fmt.Sprint(i)))
+		},
+	},
+	TokenFootnotes: Tag{
+		Before: func(s string) string { return "\n<hr />\n<ol>\n" + s },
+		After:  func(s string) string { return s + "</ol>\n" },
+	},
+	TokenFootnote: Tag{
+		TrimInside: true,
+		Footnote: func(i int, s string) string {
+			return "<li>" + confluenceMacro("anchor", confluenceParam("", fmt.Sprintf("fn-%d", i))) + s + "</li>\n"
+		},
+	},
+}
+
+// confluenceMacro returns a structured macro with the parameters and body.
+func confluenceMacro(name, body string) string {
+	if body == "" {
+		return fmt.Sprintf(`<ac:structured-macro ac:name=%q />`, name)
+	}
+
+	return fmt.Sprintf(`<ac:structured-macro ac:name=%q>`, name) + body + "</ac:structured-macro>"
+}
+
+// confluenceParam returns a macro parameter. The anchor macro's parameter
+// has no name.
+func confluenceParam(name, value string) string {
+	return fmt.Sprintf(`<ac:parameter ac:name=%q>`, name) + html.EscapeString(value) + "</ac:parameter>"
+}
+
+// confluenceCDATA wraps text in a CDATA section, splitting any ]]> that
+// would end it early.
+func confluenceCDATA(s string) string {
+	return "<![CDATA[" + strings.Replace(s, "]]>", "]]]]><![CDATA[>", -1) + "]]>"
+}
+
+// confluenceImage returns the image for the page attachment of the image,
+// with its size and alt text. Attachments have no directories, so they are
+// named by their base name, as they are in the tar bundle.
+func confluenceImage(file downloader.ManifestFile) string {
+	attrs := fmt.Sprintf(` ac:width="%d" ac:height="%d"`, file.Width, file.Height)
+
+	if file.Description != "" {
+		attrs += fmt.Sprintf(` ac:alt="%s"`, html.EscapeString(file.Description))
+	}
+
+	if file.Title != "" {
+		attrs += fmt.Sprintf(` ac:title="%s"`, html.EscapeString(file.Title))
+	}
+
+	return fmt.Sprintf(`<ac:image%s><ri:attachment ri:filename="%s" /></ac:image>`, attrs, html.EscapeString(path.Base(file.Filename)))
+}
+
+// confluenceProperties turns the front matter into a page properties macro.
+func confluenceProperties(n *Node, s string) string {
+	var rows string
+
+	if n.Title != "" {
+		rows += "<tr><th>title</th><td>" + html.EscapeString(n.Title) + "</td></tr>"
+	}
+
+	if n.Subtitle != "" {
+		rows += "<tr><th>subtitle</th><td>" + html.EscapeString(n.Subtitle) + "</td></tr>"
+	}
+
+	return confluenceMacro("details", "<ac:rich-text-body><table><tbody>"+rows+"</tbody></table></ac:rich-text-body>") + "\n"
+}
@@ -10,19 +10,71 @@ import (
 )
 
 // Convert converts google docs json types to string format documents in the format provided.
-// Formats available: md (markdown), html
+// Formats available: md (markdown), html, adoc (asciidoc), rst (reStructuredText), latex, org (Org-mode),
+// mediawiki, confluence (Confluence storage format)
 func Convert(typ string, doc *docs.Document, manifest downloader.Manifest) (string, error) {
-	node, err := Parse(doc, manifest)
+	return ConvertWithOptions(typ, doc, manifest, Options{})
+}
+
+// ConvertWithOptions is Convert with control over the optional parts of the
+// output. Documents split into pages are joined back together.
+func ConvertWithOptions(typ string, doc *docs.Document, manifest downloader.Manifest, opts Options) (string, error) {
+	pages, err := ConvertPages(typ, doc, manifest, opts)
 	if err != nil {
 		return "", err
 	}
 
-	return Generate(typ, node, manifest)
+	return strings.Join(pages, "\n"), nil
+}
+
+// ConvertPages is ConvertWithOptions, returning each page of the document
+// separately when opts.PageBreaks is PageBreaksSplit. Otherwise the whole
+// document is the only page.
+func ConvertPages(typ string, doc *docs.Document, manifest downloader.Manifest, opts Options) ([]string, error) {
+	node, err := Parse(doc, manifest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == "latex" {
+		latexFootnotes(node)
+	}
+
+	nodes := []*Node{node}
+	if opts.PageBreaks == PageBreaksSplit {
+		nodes = splitPages(node)
+	}
+
+	pages := []string{}
+
+	for _, node := range nodes {
+		res, err := Generate(typ, node, manifest, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.Standalone && typ == "latex" {
+			res = latexDocument(res)
+		}
+
+		pages = append(pages, res)
+	}
+
+	return pages, nil
 }
 
 type TagSet map[Token]Tag
 
+// pageBreakDiv breaks the page when printed. markdown passes it through as html.
+const pageBreakDiv = `<div style="page-break-after:always"></div>`
+
 var ConvertMap = map[string]TagSet{
+	"adoc":       asciidoc,
+	"rst":        restructuredText,
+	"latex":      latex,
+	"org":        org,
+	"mediawiki":  mediaWiki,
+	"confluence": confluence,
 	"md": {
 		TokenPlain: Tag{
 			Collapse: true,
@@ -45,6 +97,36 @@ var ConvertMap = map[string]TagSet{
 			Before:          func(s string) string { return "_" + s },
 			After:           func(s string) string { return s + "_" },
 		},
+		TokenStrikethrough: Tag{
+			TrimInside:      true,
+			LeftPad:         true,
+			Collapse:        true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "~~" + s },
+			After:           func(s string) string { return s + "~~" },
+		},
+		// markdown has no syntax for these, so we fall back to inline html.
+		TokenUnderline: Tag{
+			LeftPad:         true,
+			Collapse:        true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "<u>" + s },
+			After:           func(s string) string { return s + "</u>" },
+		},
+		TokenSuperscript: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			NoPadAfter:      true,
+			Before:          func(s string) string { return "<sup>" + s },
+			After:           func(s string) string { return s + "</sup>" },
+		},
+		TokenSubscript: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			NoPadAfter:      true,
+			Before:          func(s string) string { return "<sub>" + s },
+			After:           func(s string) string { return s + "</sub>" },
+		},
 		TokenParagraph: Tag{
 			TrimInside: true,
 			Before:     func(s string) string { return "\n" + s },
@@ -52,63 +134,86 @@ var ConvertMap = map[string]TagSet{
 		},
 		TokenUnorderedList: Tag{
 			SkipFirst: true,
-			Before:    func(s string) string { return "  " + s },
+			Before:    func(s string) string { return "  " + indentLines(s, "  ") },
 		},
 		TokenUnorderedBullet: Tag{
 			TrimInside:      true,
 			RequiresContent: true,
-			Before:          func(s string) string { return "* " + s },
+			Before:          func(s string) string { return "* " + indentLines(s, "  ") },
 			After:           func(s string) string { return s + "\n" },
 		},
 		TokenOrderedList: Tag{
 			SkipFirst: true,
-			Before:    func(s string) string { return "  " + s },
+			Before:    func(s string) string { return "  " + indentLines(s, "  ") },
 		},
 		TokenOrderedBullet: Tag{
 			TrimInside:      true,
 			RequiresContent: true,
-			ListBefore:      func(s string, i int) string { return fmt.Sprintf("%d. ", i) + s },
-			After:           func(s string) string { return s + "\n" },
+			NodeBefore: func(n *Node, s string) string {
+				marker := fmt.Sprintf("%d. ", n.ListNumber)
+				return marker + indentLines(s, strings.Repeat(" ", len(marker)))
+			},
+			After: func(s string) string { return s + "\n" },
+		},
+		TokenCheckList: Tag{
+			SkipFirst: true,
+			Before:    func(s string) string { return "  " + indentLines(s, "  ") },
+		},
+		TokenCheckBullet: Tag{
+			TrimInside:      true,
+			RequiresContent: true,
+			NodeBefore: func(n *Node, s string) string {
+				if n.Checked {
+					return "* [x] " + s
+				}
+				return "* [ ] " + s
+			},
+			After: func(s string) string { return s + "\n" },
 		},
 		TokenHeading: Tag{
 			TrimInside:      true,
 			RequiresContent: true,
 			Repeat:          func(times int, s string) string { return strings.Repeat("#", times) + " " + s },
 			After:           func(s string) string { return s + "\n" },
+			OptionalAnchor:  true,
+			Anchor:          func(anchor, s string) string { return fmt.Sprintf("<a id=%q></a>\n", anchor) + s },
 		},
 		TokenTable: Tag{
-			Before: func(s string) string { return "<table>" + s },
-			After:  func(s string) string { return s + "</table>" },
-		},
-		TokenTableCell: Tag{
-			TrimInside: true,
-			Before:     func(s string) string { return "<td>" + s },
-			After:      func(s string) string { return s + "</td>" },
-		},
-		TokenTableRow: Tag{
-			Before: func(s string) string { return "<tr>" + s },
-			After:  func(s string) string { return s + "</tr>" },
+			// tables pipe tables can't express are html, with their cells in
+			// html too, as markdown isn't parsed inside html blocks
+			Table:    markdownTable,
+			Fallback: "html",
+			// html blocks only end at a blank line
+			After: func(s string) string { return s + "\n" },
 		},
+		// the rest of the table is generated by markdownTable or as html
+		TokenTableHead:       Tag{},
+		TokenTableHeaderCell: Tag{},
+		TokenTableCell:       Tag{},
+		TokenTableRow:        Tag{},
 		TokenImage: Tag{
-			MapFile: func(file downloader.ManifestFile) string {
-				return fmt.Sprintf("<img src=%q height=%d width=%d />", file.Filename, file.Height, file.Width)
-			},
+			MapFile: imageTag,
 		},
 		TokenCode: Tag{
 			Collapse:        true,
 			NoEscape:        true,
 			RequiresContent: true,
-			Before: func(s string) string {
+			NoPadAfter:      true,
+			NodeBefore: func(n *Node, s string) string {
 				if strings.Contains(s, "\n") {
-					return "```\n" + s
+					return "```" + n.Language + "\n" + s
+				}
+				// inline code is finished here; After only closes blocks.
+				if strings.Contains(s, "`") {
+					return "`` " + s + " ``"
 				}
-				return "`" + s
+				return "`" + s + "`"
 			},
 			After: func(s string) string {
-				if strings.Contains(s, "\n") {
-					return s + "\n```\n"
+				if strings.HasPrefix(s, "```") && strings.Contains(s, "\n") {
+					return strings.TrimRight(s, "\n") + "\n```\n"
 				}
-				return s + "`"
+				return s
 			},
 		},
 		TokenLink: Tag{
@@ -117,23 +222,111 @@ var ConvertMap = map[string]TagSet{
 				return fmt.Sprintf("[%s](%s)", markdownEscape(s), href)
 			},
 		},
+		TokenTOC: Tag{
+			After: func(s string) string { return s + "\n" },
+		},
+		TokenTOCList: Tag{
+			Before: func(s string) string { return "\n" + s },
+		},
+		TokenTOCEntry: Tag{
+			TrimInside: true,
+			// nested lists are indented under their parent entry
+			Before: func(s string) string { return "* " + indentLines(s, "  ") },
+			After:  func(s string) string { return s + "\n" },
+		},
+		TokenLineBreak: Tag{
+			NoPadAfter: true,
+			Before:     func(s string) string { return "\\\n" },
+		},
+		TokenSubtitle: Tag{
+			// markdown is not rendered inside html blocks
+			NoEscape:   true,
+			TrimInside: true,
+			Before:     func(s string) string { return "\n<p class=\"subtitle\">" + html.EscapeString(s) },
+			After:      func(s string) string { return s + "</p>\n" },
+		},
+		TokenFrontMatter: Tag{
+			NodeBefore: yamlFrontMatter,
+		},
+		TokenBlockquote: Tag{
+			TrimInside: true,
+			Before:     func(s string) string { return "\n" + prefixLines(s, "> ") + "\n" },
+		},
+		TokenAdmonition: Tag{
+			TrimInside: true,
+			NodeBefore: markdownAdmonition,
+		},
+		// rules set headers and footers apart from the body
+		TokenHeader: Tag{
+			After: func(s string) string { return s + "\n---\n" },
+		},
+		TokenFooter: Tag{
+			Before: func(s string) string { return "\n---\n" + s },
+		},
+		// CriticMarkup
+		TokenInsertion: Tag{
+			NoPadAfter:      true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "{++" + s },
+			After:           func(s string) string { return s + "++}" },
+		},
+		TokenDeletion: Tag{
+			NoPadAfter:      true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "{--" + s },
+			After:           func(s string) string { return s + "--}" },
+		},
+		TokenMath: Tag{
+			NoEscape:   true,
+			NoPadAfter: true,
+			Before:     func(s string) string { return "$" + s },
+			After:      func(s string) string { return s + "$" },
+		},
+		TokenMathBlock: Tag{
+			NoEscape: true,
+			Before:   func(s string) string { return "$$\n" + s },
+			After:    func(s string) string { return s + "\n$$" },
+		},
+		TokenFloat: Tag{
+			RequiresContent: true,
+			Before:          func(s string) string { return "\n" + s },
+			After:           func(s string) string { return s + "\n" },
+		},
+		TokenFigure: Tag{
+			Before: func(s string) string { return "\n<figure>" + s },
+			After:  func(s string) string { return s + "</figure>\n" },
+		},
+		TokenFigureCaption: Tag{
+			// markdown is not rendered inside html blocks
+			Fallback: "html",
+		},
+		TokenHorizontalRule: Tag{
+			// blank lines keep the rule from turning the text above into a heading
+			Before: func(s string) string { return "\n\n---\n\n" },
+		},
+		TokenPageBreak: Tag{
+			// html blocks need blank lines around them
+			Before: func(s string) string { return "\n" + pageBreakDiv + "\n" },
+		},
+		TokenFootnoteRef: Tag{
+			NoPadAfter: true,
+			Footnote:   func(i int, s string) string { return fmt.Sprintf("[^%d]", i) },
+		},
+		TokenFootnotes: Tag{
+			Before: func(s string) string { return "\n" + s },
+		},
+		TokenFootnote: Tag{
+			TrimInside: true,
+			Footnote: func(i int, s string) string {
+				// continuation paragraphs must be indented to stay in the footnote
+				return fmt.Sprintf("[^%d]: %s\n", i, indentLines(s, "    "))
+			},
+		},
 	},
 	"html": {
 		TokenPlain: Tag{
 			Collapse: true,
-			Escape: func(s string) string {
-				if len(s) > 0 {
-					s = html.EscapeString(s)
-					if s[0] == ' ' {
-						s = "&nbsp;" + s[1:]
-					}
-					if s[len(s)-1] == ' ' {
-						s = s[:len(s)-1] + "&nbsp;"
-					}
-				}
-
-				return s
-			},
+			Escape:   htmlEscape,
 		},
 		TokenBold: Tag{
 			Collapse:   true,
@@ -148,6 +341,32 @@ var ConvertMap = map[string]TagSet{
 			Before:          func(s string) string { return "<i>" + s },
 			After:           func(s string) string { return s + "</i>" },
 		},
+		TokenStrikethrough: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			TrimInside:      true,
+			Before:          func(s string) string { return "<s>" + s },
+			After:           func(s string) string { return s + "</s>" },
+		},
+		TokenUnderline: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			TrimInside:      true,
+			Before:          func(s string) string { return "<u>" + s },
+			After:           func(s string) string { return s + "</u>" },
+		},
+		TokenSuperscript: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "<sup>" + s },
+			After:           func(s string) string { return s + "</sup>" },
+		},
+		TokenSubscript: Tag{
+			Collapse:        true,
+			RequiresContent: true,
+			Before:          func(s string) string { return "<sub>" + s },
+			After:           func(s string) string { return s + "</sub>" },
+		},
 		TokenParagraph: Tag{
 			LeftPad:         true,
 			TrimInside:      true,
@@ -164,25 +383,60 @@ var ConvertMap = map[string]TagSet{
 			After:  func(s string) string { return s + "</li>" },
 		},
 		TokenOrderedList: Tag{
-			Before: func(s string) string { return "<ol>" + s },
-			After:  func(s string) string { return s + "</ol>" },
+			NodeBefore: func(n *Node, s string) string {
+				switch n.ListType {
+				case "", "1":
+					return "<ol>" + s
+				case "01":
+					return `<ol style="list-style-type: decimal-leading-zero">` + s
+				default:
+					return fmt.Sprintf("<ol type=%q>", n.ListType) + s
+				}
+			},
+			After: func(s string) string { return s + "</ol>" },
 		},
 		TokenOrderedBullet: Tag{
-			ListBefore: func(s string, i int) string { return fmt.Sprintf(`<li value="%d">`, i) + s },
+			NodeBefore: func(n *Node, s string) string { return fmt.Sprintf(`<li value="%d">`, n.ListNumber) + s },
 			After:      func(s string) string { return s + "</li>" },
 		},
+		TokenCheckList: Tag{
+			Before: func(s string) string { return `<ul class="checklist">` + s },
+			After:  func(s string) string { return s + "</ul>" },
+		},
+		TokenCheckBullet: Tag{
+			NodeBefore: func(n *Node, s string) string {
+				if n.Checked {
+					return `<li><input type="checkbox" disabled checked />` + s
+				}
+				return `<li><input type="checkbox" disabled />` + s
+			},
+			After: func(s string) string { return s + "</li>" },
+		},
 		TokenHeading: Tag{
 			TrimInside:      true,
 			RequiresContent: true,
 			Repeat:          func(times int, s string) string { return fmt.Sprintf("<h%d>%s</h%d>", times, s, times) },
+			Anchor: func(anchor, s string) string {
+				// add the id to the opening tag generated by Repeat
+				return strings.Replace(s, ">", fmt.Sprintf(" id=%q>", anchor), 1)
+			},
 		},
 		TokenTable: Tag{
 			Before: func(s string) string { return "<table>" + s },
 			After:  func(s string) string { return s + "</table>" },
 		},
+		TokenTableHead: Tag{
+			Before: func(s string) string { return "<thead>" + s },
+			After:  func(s string) string { return s + "</thead>" },
+		},
+		TokenTableHeaderCell: Tag{
+			TrimInside: true,
+			NodeBefore: func(n *Node, s string) string { return "<th" + spanAttrs(n.ColSpan, n.RowSpan) + ">" + s },
+			After:      func(s string) string { return s + "</th>" },
+		},
 		TokenTableCell: Tag{
 			TrimInside: true,
-			Before:     func(s string) string { return "<td>" + s },
+			NodeBefore: func(n *Node, s string) string { return "<td" + spanAttrs(n.ColSpan, n.RowSpan) + ">" + s },
 			After:      func(s string) string { return s + "</td>" },
 		},
 		TokenTableRow: Tag{
@@ -190,16 +444,16 @@ var ConvertMap = map[string]TagSet{
 			After:  func(s string) string { return s + "</tr>" },
 		},
 		TokenImage: Tag{
-			MapFile: func(file downloader.ManifestFile) string {
-				return fmt.Sprintf("<img src=%q height=%d width=%d />", file.Filename, file.Height, file.Width)
-			},
+			MapFile: imageTag,
 		},
 		TokenCode: Tag{
 			Collapse:        true,
 			RequiresContent: true,
-			LeftPad:         true,
-			Before: func(s string) string {
+			NodeBefore: func(n *Node, s string) string {
 				if strings.Contains(s, "\n") {
+					if n.Language != "" {
+						return fmt.Sprintf("\n<pre><code class=%q>", "language-"+n.Language) + s
+					}
 					return "\n<pre><code>" + s
 				}
 				return "<code>" + s
@@ -217,5 +471,131 @@ var ConvertMap = map[string]TagSet{
 				return fmt.Sprintf("<a href=%q>%s</a>", href, s)
 			},
 		},
+		TokenTOC: Tag{
+			Before: func(s string) string { return "<nav class=\"toc\">" + s },
+			After:  func(s string) string { return s + "</nav>\n" },
+		},
+		TokenTOCList: Tag{
+			Before: func(s string) string { return "<ul>" + s },
+			After:  func(s string) string { return s + "</ul>" },
+		},
+		TokenTOCEntry: Tag{
+			Before: func(s string) string { return "<li>" + s },
+			After:  func(s string) string { return s + "</li>" },
+		},
+		TokenLineBreak: Tag{
+			Before: func(s string) string { return "<br />\n" },
+		},
+		TokenSubtitle: Tag{
+			NoPadAfter:      true,
+			TrimInside:      true,
+			RequiresContent: true,
+			Before:          func(s string) string { return `<p class="subtitle">` + s },
+			After:           func(s string) string { return s + "</p>\n" },
+		},
+		TokenFrontMatter: Tag{
+			NoPadAfter: true,
+			NodeBefore: yamlFrontMatter,
+		},
+		TokenBlockquote: Tag{
+			NoPadAfter: true,
+			Before:     func(s string) string { return "<blockquote>\n" + s },
+			After:      func(s string) string { return s + "</blockquote>\n" },
+		},
+		TokenAdmonition: Tag{
+			NoPadAfter: true,
+			NodeBefore: func(n *Node, s string) string {
+				return fmt.Sprintf("<div class=\"admonition %s\">\n<p class=\"admonition-title\">%s</p>\n%s", n.Admonition, admonitionTitles[n.Admonition], s)
+			},
+			After: func(s string) string { return s + "</div>\n" },
+		},
+		TokenHeader: Tag{
+			NoPadAfter: true,
+			Before:     func(s string) string { return "<header>\n" + s },
+			After:      func(s string) string { return s + "</header>\n" },
+		},
+		TokenFooter: Tag{
+			Before: func(s string) string { return "<footer>\n" + s },
+			After:  func(s string) string { return s + "</footer>\n" },
+		},
+		TokenInsertion: Tag{
+			RequiresContent: true,
+			Before:          func(s string) string { return "<ins>" + s },
+			After:           func(s string) string { return s + "</ins>" },
+		},
+		TokenDeletion: Tag{
+			RequiresContent: true,
+			Before:          func(s string) string { return "<del>" + s },
+			After:           func(s string) string { return s + "</del>" },
+		},
+		// KaTeX's auto-render and MathJax both pick these up.
+		TokenMath: Tag{
+			Before: func(s string) string { return `<span class="math inline">\(` + s },
+			After:  func(s string) string { return s + `\)</span>` },
+		},
+		TokenMathBlock: Tag{
+			Before: func(s string) string { return `<span class="math display">\[` + s },
+			After:  func(s string) string { return s + `\]</span>` },
+		},
+		TokenFloat: Tag{
+			NoPadAfter:      true,
+			RequiresContent: true,
+			NodeBefore: func(n *Node, s string) string {
+				if n.Float != "" {
+					return fmt.Sprintf(`<div style="float: %s">`, n.Float) + s
+				}
+				return "<div>" + s
+			},
+			After: func(s string) string { return s + "</div>\n" },
+		},
+		TokenFigure: Tag{
+			NoPadAfter: true,
+			Before:     func(s string) string { return "<figure>" + s },
+			After:      func(s string) string { return s + "</figure>\n" },
+		},
+		TokenFigureCaption: Tag{
+			TrimInside: true,
+			Before:     func(s string) string { return "<figcaption>" + s },
+			After:      func(s string) string { return s + "</figcaption>" },
+		},
+		TokenHorizontalRule: Tag{
+			Before: func(s string) string { return "<hr />" },
+		},
+		TokenPageBreak: Tag{
+			NoPadAfter: true,
+			Before:     func(s string) string { return pageBreakDiv + "\n" },
+		},
+		TokenFootnoteRef: Tag{
+			NodeBefore: func(n *Node, s string) string {
+				return fmt.Sprintf(`<sup id="%s"><a href="#fn-%d">%d</a></sup>`, htmlFootnoteRef(n.FootnoteNum, n.FootnoteRef), n.FootnoteNum, n.FootnoteNum)
+			},
+		},
+		TokenFootnotes: Tag{
+			Before: func(s string) string { return "\n<section class=\"footnotes\">\n<ol>\n" + s },
+			After:  func(s string) string { return s + "</ol>\n</section>\n" },
+		},
+		TokenFootnote: Tag{
+			TrimInside: true,
+			NodeBefore: func(n *Node, s string) string {
+				// a backlink to every reference, numbered after the first
+				var backlinks string
+				for ref := 1; ref <= n.FootnoteRef; ref++ {
+					backlinks += fmt.Sprintf(` <a href="#%s">&#8617;`, htmlFootnoteRef(n.FootnoteNum, ref))
+					if ref > 1 {
+						backlinks += fmt.Sprintf("<sup>%d</sup>", ref)
+					}
+					backlinks += "</a>"
+				}
+
+				// keep the backlinks inside the last paragraph if there is one
+				if idx := strings.LastIndex(s, "</p>"); idx >= 0 {
+					s = s[:idx] + backlinks + s[idx:]
+				} else {
+					s += backlinks
+				}
+
+				return fmt.Sprintf(`<li id="fn-%d">%s</li>`+"\n", n.FootnoteNum, s)
+			},
+		},
 	},
 }
@@ -57,8 +57,18 @@ func TestFixtures(t *testing.T) {
 			f.Close()
 		}
 
-		for _, typ := range []string{"md", "html"} {
-			out, err := Convert(typ, doc, manifest)
+		var opts Options
+
+		if f, err := os.Open(filepath.Join(dir, "options.json")); err == nil {
+			if err := json.NewDecoder(f).Decode(&opts); err != nil {
+				t.Fatalf("%q: could not decode options: %v", name, err)
+			}
+
+			f.Close()
+		}
+
+		for _, typ := range []string{"md", "html", "adoc", "rst", "latex", "org", "mediawiki", "confluence"} {
+			out, err := ConvertWithOptions(typ, doc, manifest, opts)
 			if err != nil {
 				t.Fatalf("while converting %q to %q: %v", name, typ, err)
 			}
@@ -78,3 +88,69 @@ func TestFixtures(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertPages(t *testing.T) {
+	f, err := os.Open(filepath.Join(testdataDir, "breaks", "breaks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc := &docs.Document{}
+	if err := json.NewDecoder(f).Decode(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, typ := range []string{"md", "html"} {
+		pages, err := ConvertPages(typ, doc, downloader.Manifest{}, Options{PageBreaks: PageBreaksSplit})
+		if err != nil {
+			t.Fatalf("while converting to %q: %v", typ, err)
+		}
+
+		if len(pages) != 3 {
+			t.Fatalf("%q: expected 3 pages, got %d", typ, len(pages))
+		}
+
+		for i, heading := range []string{"Breaks", "Second page", "Third page"} {
+			if !strings.Contains(pages[i], heading) {
+				t.Fatalf("%q: page %d does not contain %q", typ, i+1, heading)
+			}
+
+			if strings.Contains(pages[i], "page-break-after") {
+				t.Fatalf("%q: page %d still contains a page break", typ, i+1)
+			}
+		}
+
+		// footnotes are on the pages that reference them
+		for i, footnotes := range [][]string{{"Noted on the first page"}, nil, {"Noted on the last page"}} {
+			for _, footnote := range []string{"Noted on the first page", "Noted on the last page"} {
+				want := len(footnotes) > 0 && footnotes[0] == footnote
+				if strings.Contains(pages[i], footnote) != want {
+					t.Fatalf("%q: page %d: expected footnote %q to be there: %v", typ, i+1, footnote, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBookmarkWarning(t *testing.T) {
+	f, err := os.Open(filepath.Join(testdataDir, "anchors", "anchors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc := &docs.Document{}
+	if err := json.NewDecoder(f).Decode(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings bytes.Buffer
+	if _, err := ConvertWithOptions("md", doc, downloader.Manifest{}, Options{Warnings: &warnings}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(warnings.String(), "warning: dropping link to bookmark id.bm1") {
+		t.Fatalf("expected a warning for the bookmark link, got %q", warnings.String())
+	}
+}
@@ -0,0 +1,113 @@
+package converters
+
+import (
+	"regexp"
+	"strings"
+)
+
+// captionRegexp matches the numbered labels that start captions.
+var captionRegexp = regexp.MustCompile(`^(Figure|Fig\.)\s*\d+`)
+
+// parseFigures replaces paragraphs holding only an image, followed by a
+// caption, with a figure.
+func parseFigures(node *Node) {
+	children := []*Node{}
+
+	for i := 0; i < len(node.Children); i++ {
+		child := node.Children[i]
+
+		if i+1 < len(node.Children) && isImageParagraph(child) && isCaption(node.Children[i+1]) {
+			figure := &Node{Token: TokenFigure, parent: node}
+
+			for _, c := range child.Children {
+				if c.Token == TokenImage {
+					figure.append(c)
+				}
+			}
+
+			// the caption keeps its styles and links
+			caption := figure.append(&Node{Token: TokenFigureCaption})
+			for _, c := range node.Children[i+1].Children {
+				caption.append(c)
+			}
+			children = append(children, figure)
+			i++
+			continue
+		}
+
+		parseFigures(child)
+		children = append(children, child)
+	}
+
+	node.Children = children
+}
+
+// isImageParagraph reports whether the node is a paragraph of a single image.
+func isImageParagraph(node *Node) bool {
+	if node.Token != TokenParagraph {
+		return false
+	}
+
+	var images int
+
+	for _, child := range node.Children {
+		switch {
+		case child.Token == TokenImage:
+			images++
+		case child.Token == TokenPlain && strings.TrimSpace(child.Content) == "":
+		default:
+			return false
+		}
+	}
+
+	return images == 1
+}
+
+// isCaption reports whether the node is a paragraph that captions an image:
+// centered, entirely in italics, or starting with a label like "Figure 2".
+func isCaption(node *Node) bool {
+	if node.Token != TokenParagraph || len(node.Children) == 0 {
+		return false
+	}
+
+	for _, child := range node.Children {
+		if child.Token == TokenHeading {
+			return false
+		}
+	}
+
+	text := strings.TrimSpace(nodeText(node))
+	if text == "" {
+		return false
+	}
+
+	return node.Centered || captionRegexp.MatchString(text) || isItalic(node, false)
+}
+
+// isItalic reports whether all the text under the node is in italics.
+func isItalic(node *Node, italic bool) bool {
+	italic = italic || node.Token == TokenItalic
+
+	if node.Token == TokenPlain && strings.TrimSpace(node.Content) != "" && !italic {
+		return false
+	}
+
+	for _, child := range node.Children {
+		if !isItalic(child, italic) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodeText returns the text of the node and its children.
+func nodeText(node *Node) string {
+	text := node.Content
+
+	for _, child := range node.Children {
+		text += nodeText(child)
+	}
+
+	return text
+}
@@ -0,0 +1,88 @@
+package converters
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// parseFrontMatter records the paragraph as the title or subtitle of the
+// front matter if it is the first in its style, and reports whether it did.
+func (p *parser) parseFrontMatter(style string, para *docs.Paragraph) bool {
+	var field *string
+
+	switch style {
+	case "TITLE":
+		field = &p.frontMatter.Title
+	case "SUBTITLE":
+		field = &p.frontMatter.Subtitle
+	default:
+		return false
+	}
+
+	if *field != "" {
+		return false
+	}
+
+	var text string
+	for _, pelem := range para.Elements {
+		if pelem.TextRun != nil {
+			text += strings.Replace(pelem.TextRun.Content, "\u000b", " ", -1)
+		}
+	}
+
+	*field = strings.TrimSpace(text)
+	return *field != ""
+}
+
+// prependFrontMatter puts the front matter at the start of the document, if
+// a title or subtitle was found.
+func (p *parser) prependFrontMatter(node *Node) {
+	fm := p.frontMatter
+	if fm.Title == "" && fm.Subtitle == "" {
+		return
+	}
+
+	fm.parent = node
+	node.Children = append([]*Node{fm}, node.Children...)
+}
+
+// yamlFrontMatter writes the title and subtitle of the front matter node as
+// YAML front matter.
+func yamlFrontMatter(n *Node, s string) string {
+	yaml := "---\n"
+
+	if n.Title != "" {
+		yaml += "title: " + yamlString(n.Title) + "\n"
+	}
+
+	if n.Subtitle != "" {
+		yaml += "subtitle: " + yamlString(n.Subtitle) + "\n"
+	}
+
+	return yaml + "---\n"
+}
+
+// yamlString quotes s as a YAML double quoted scalar.
+func yamlString(s string) string {
+	var b strings.Builder
+
+	b.WriteByte('"')
+
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
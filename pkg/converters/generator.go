@@ -8,13 +8,12 @@ import (
 	"github.com/erikh/gdocs-export/pkg/downloader"
 )
 
-func Generate(typ string, node *Node, manifest downloader.Manifest) (string, error) {
+func Generate(typ string, node *Node, manifest downloader.Manifest, opts Options) (string, error) {
 	converter, ok := ConvertMap[typ]
 	if !ok {
 		return "", fmt.Errorf("%q is an invalid format. Try `-c help`", typ)
 	}
 
-	var res string
 	tag, ok := converter[node.Token]
 	if !ok {
 		return "", fmt.Errorf("Parser is broken: missing handler for token %q", node.Token)
@@ -32,41 +31,30 @@ func Generate(typ string, node *Node, manifest downloader.Manifest) (string, err
 		return "", errors.New("filename was yielded yet no handler could be found for the token")
 	}
 
-	res = strings.Replace(node.Content, "\u000b", "\n\n", -1)
-
-	noEscape := false
-
-	for n := node; n != nil; n = n.parent {
-		t := converter[n.Token]
-		if t.NoEscape {
-			noEscape = true
-			break
-		}
-	}
-
-	var (
-		lastSib *Node
-	)
-
-	for _, sib := range node.Children {
-		tmp, err := Generate(typ, sib, manifest)
+	if tag.Table != nil || tag.GridTable != nil {
+		res, ok, err := generateTable(typ, tag, node, manifest, opts)
 		if err != nil {
 			return "", err
 		}
 
-		sibConv := converter[sib.Token]
-
-		if lastSib != nil && lastSib.Token != sib.Token && sibConv.LeftPad && (res == "" || res[len(res)-1] != ' ' || res[len(res)-1] != '\n') &&
-			tmp != "" && tmp[0] != ' ' && tmp[0] != '\n' {
-			res += " "
+		if ok {
+			return res, nil
 		}
+	}
 
-		if sibConv.Escape != nil && !noEscape && !sibConv.NoEscape {
-			tmp = sibConv.Escape(tmp)
-		}
+	var (
+		res string
+		err error
+	)
 
-		res += tmp
-		lastSib = sib
+	// nodes with a fallback are generated as that format would
+	if tag.Fallback != "" {
+		res, err = Generate(tag.Fallback, node, manifest, opts)
+	} else {
+		res, err = generateContent(typ, converter, node, manifest, opts)
+	}
+	if err != nil {
+		return "", err
 	}
 
 	if tag.TrimInside {
@@ -88,13 +76,13 @@ func Generate(typ string, node *Node, manifest downloader.Manifest) (string, err
 
 	parent := node.parent
 
-	if tag.Before != nil || tag.ListBefore != nil {
+	if tag.Before != nil || tag.NodeBefore != nil {
 		switch {
 		case tag.SkipFirst && (parent == nil || parent.Token != node.Token):
 		case tag.Collapse && parent != nil && parent.Token == node.Token:
 		default:
-			if tag.ListBefore != nil {
-				res = tag.ListBefore(res, node.ListNumber)
+			if tag.NodeBefore != nil {
+				res = tag.NodeBefore(node, res)
 			} else {
 				res = tag.Before(res)
 			}
@@ -110,9 +98,92 @@ func Generate(typ string, node *Node, manifest downloader.Manifest) (string, err
 		}
 	}
 
+	if tag.Anchor != nil && node.Anchor != "" && (!tag.OptionalAnchor || opts.Anchors) {
+		res = tag.Anchor(node.Anchor, res)
+	}
+
+	if tag.Footnote != nil {
+		res = tag.Footnote(node.FootnoteNum, res)
+	}
+
 	if tag.Link != nil && node.Url != "" {
 		res = tag.Link(node.Url, res)
 	}
 
 	return res, nil
 }
+
+// generateContent generates the content and children of the node, without
+// applying the node's own tag.
+func generateContent(typ string, converter TagSet, node *Node, manifest downloader.Manifest, opts Options) (string, error) {
+	res := node.Content
+
+	noEscape := false
+
+	for n := node; n != nil; n = n.parent {
+		t := converter[n.Token]
+		if t.NoEscape {
+			noEscape = true
+			break
+		}
+	}
+
+	var (
+		lastSib *Node
+	)
+
+	for _, sib := range mergeSiblings(converter, node.Children) {
+		tmp, err := Generate(typ, sib, manifest, opts)
+		if err != nil {
+			return "", err
+		}
+
+		sibConv := converter[sib.Token]
+
+		if lastSib != nil && lastSib.Token != sib.Token && sibConv.LeftPad && !converter[lastSib.Token].NoPadAfter &&
+			tmp != "" && tmp[0] != ' ' && tmp[0] != '\n' {
+			res += " "
+		}
+
+		if sibConv.Escape != nil && !noEscape && !sibConv.NoEscape {
+			tmp = sibConv.Escape(tmp)
+		}
+
+		res += tmp
+		lastSib = sib
+	}
+
+	return res, nil
+}
+
+// mergeSiblings merges runs of sibling nodes whose tag has Merge set into
+// one node with all of their children, for formats where each list item
+// can't be a list of its own. Lists of different types are not merged.
+func mergeSiblings(converter TagSet, children []*Node) []*Node {
+	merged := []*Node{}
+
+	var copied *Node
+
+	for _, child := range children {
+		if n := len(merged); n > 0 && converter[child.Token].Merge {
+			last := merged[n-1]
+
+			if last.Token == child.Token && last.ListType == child.ListType {
+				// copy the node, so the tree is left as it is
+				if last != copied {
+					c := *last
+					c.Children = append([]*Node{}, last.Children...)
+					copied = &c
+					merged[n-1] = copied
+				}
+
+				copied.Children = append(copied.Children, child.Children...)
+				continue
+			}
+		}
+
+		merged = append(merged, child)
+	}
+
+	return merged
+}
@@ -0,0 +1,88 @@
+package converters
+
+import "google.golang.org/api/docs/v1"
+
+// parseHeaders appends the headers of the document, in the order their pages
+// come in: the first page header, the default header, and the even page
+// header.
+func (p *parser) parseHeaders(node *Node) error {
+	style := p.doc.DocumentStyle
+	if style == nil {
+		return nil
+	}
+
+	content := [][]*docs.StructuralElement{}
+
+	for _, id := range variantIDs(style, style.FirstPageHeaderId, style.DefaultHeaderId, style.EvenPageHeaderId) {
+		if header, ok := p.doc.Headers[id]; ok {
+			content = append(content, header.Content)
+		}
+	}
+
+	return p.parseSection(node, TokenHeader, content)
+}
+
+// parseFooters appends the footers of the document, in the same order as
+// parseHeaders.
+func (p *parser) parseFooters(node *Node) error {
+	style := p.doc.DocumentStyle
+	if style == nil {
+		return nil
+	}
+
+	content := [][]*docs.StructuralElement{}
+
+	for _, id := range variantIDs(style, style.FirstPageFooterId, style.DefaultFooterId, style.EvenPageFooterId) {
+		if footer, ok := p.doc.Footers[id]; ok {
+			content = append(content, footer.Content)
+		}
+	}
+
+	return p.parseSection(node, TokenFooter, content)
+}
+
+// parseSection appends the content under a single node of the token, if
+// there is any.
+func (p *parser) parseSection(node *Node, token Token, content [][]*docs.StructuralElement) error {
+	if len(content) == 0 {
+		return nil
+	}
+
+	sectionNode := node.append(&Node{Token: token})
+
+	for _, elems := range content {
+		for _, elem := range elems {
+			if err := p.parseElement(elem, sectionNode); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// variantIDs returns the ids of the header or footer variants in use, without
+// duplicates.
+func variantIDs(style *docs.DocumentStyle, first, def, even string) []string {
+	candidates := []string{def}
+
+	if style.UseFirstPageHeaderFooter {
+		candidates = append([]string{first}, candidates...)
+	}
+
+	if style.UseEvenPageHeaderFooter {
+		candidates = append(candidates, even)
+	}
+
+	ids := []string{}
+	seen := map[string]bool{}
+
+	for _, id := range candidates {
+		if id != "" && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+
+	return ids
+}
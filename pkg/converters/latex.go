@@ -0,0 +1,578 @@
+package converters
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+// latexSections are the sectioning commands for each heading level.
+var latexSections = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+// latexPreamble starts a standalone document, with the packages the body
+// may use.
+const latexPreamble = `\documentclass{article}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{textcomp}
+\usepackage{amssymb}
+\usepackage{graphicx}
+\usepackage{listings}
+\usepackage{multirow}
+\usepackage[normalem]{ulem}
+\usepackage{amsmath}
+\usepackage{hyperref}
+
+\begin{document}
+`
+
+var latex = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		LeftPad:  true,
+		Escape:   latexEscape,
+	},
+	TokenBold: Tag{
+		Collapse:        true,
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\textbf{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenItalic: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\emph{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenStrikethrough: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\sout{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenUnderline: Tag{
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\uline{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return `\textsuperscript{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return `\textsubscript{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenParagraph: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n" + s },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// every list item is a list of its own in the tree, so they are merged
+	// back into one environment.
+	TokenUnorderedList: Tag{
+		Merge:  true,
+		Before: func(s string) string { return latexList("itemize", s) },
+		After:  func(s string) string { return s + "\\end{itemize}\n" },
+	},
+	TokenUnorderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\item ` + s },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenOrderedList: Tag{
+		Merge:  true,
+		Before: func(s string) string { return latexList("enumerate", s) },
+		After:  func(s string) string { return s + "\\end{enumerate}\n" },
+	},
+	TokenOrderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\item ` + s },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenCheckList: Tag{
+		Merge:  true,
+		Before: func(s string) string { return latexList("itemize", s) },
+		After:  func(s string) string { return s + "\\end{itemize}\n" },
+	},
+	TokenCheckBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			if n.Checked {
+				return `\item[{[x]}] ` + s
+			}
+			return `\item[{[ ]}] ` + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat: func(times int, s string) string {
+			if times > len(latexSections) {
+				times = len(latexSections)
+			}
+			return `\` + latexSections[times-1] + "{" + s + "}"
+		},
+		Anchor: func(anchor, s string) string { return s + `\label{` + latexLabel(anchor) + "}" },
+	},
+	TokenTable: Tag{
+		GridTable: latexTabular,
+	},
+	// tables are laid out by GridTable
+	TokenTableHead:       Tag{},
+	TokenTableHeaderCell: Tag{},
+	TokenTableCell:       Tag{},
+	TokenTableRow:        Tag{},
+	TokenImage: Tag{
+		MapFile: func(file downloader.ManifestFile) string {
+			return fmt.Sprintf(`\includegraphics[width=%dpt,height=%dpt]{%s}`, file.Width, file.Height, file.Filename)
+		},
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				s = strings.TrimRight(s, "\n")
+				if lang, ok := latexListingsLanguages[strings.ToLower(n.Language)]; ok {
+					return "\n\\begin{lstlisting}[language=" + lang + "]\n" + s + "\n\\end{lstlisting}\n"
+				}
+				return "\n\\begin{verbatim}\n" + s + "\n\\end{verbatim}\n"
+			}
+			return `\texttt{` + latexEscape(s) + "}"
+		},
+	},
+	TokenLink: Tag{
+		LeftPad: true,
+		Link: func(href, s string) string {
+			if strings.HasPrefix(href, "#") {
+				return `\hyperref[` + latexLabel(href[1:]) + "]{" + s + "}"
+			}
+			return `\href{` + latexURL(href) + "}{" + s + "}"
+		},
+	},
+	// latex builds its own table of contents from the sections
+	TokenTOC: Tag{
+		Before: func(s string) string { return "\n\\tableofcontents\n" },
+	},
+	TokenTOCList:  Tag{},
+	TokenTOCEntry: Tag{},
+	TokenLineBreak: Tag{
+		NoPadAfter: true,
+		// \\ would end the row in table cells
+		Before: func(s string) string { return "\\newline\n" },
+	},
+	TokenSubtitle: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n\\begin{center}\n\\large " + s },
+		After:      func(s string) string { return s + "\n\\end{center}\n" },
+	},
+	TokenFrontMatter: Tag{
+		NodeBefore: latexTitle,
+	},
+	TokenBlockquote: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n\\begin{quote}\n" + s },
+		After:      func(s string) string { return s + "\n\\end{quote}\n" },
+	},
+	TokenAdmonition: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			return "\n\\begin{quote}\n\\textbf{" + admonitionTitles[n.Admonition] + ":} " + s
+		},
+		After: func(s string) string { return s + "\n\\end{quote}\n" },
+	},
+	TokenHeader: Tag{
+		After: func(s string) string { return s + "\n" + latexRule + "\n" },
+	},
+	TokenFooter: Tag{
+		Before: func(s string) string { return "\n" + latexRule + "\n" + s },
+	},
+	TokenInsertion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\uline{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenDeletion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return `\sout{` + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenMath: Tag{
+		NoEscape:   true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "$" + s },
+		After:      func(s string) string { return s + "$" },
+	},
+	TokenMathBlock: Tag{
+		NoEscape: true,
+		Before:   func(s string) string { return "\\[\n" + s },
+		After:    func(s string) string { return s + "\n\\]" },
+	},
+	TokenFloat: Tag{
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			env := "center"
+			switch n.Float {
+			case "left":
+				env = "flushleft"
+			case "right":
+				env = "flushright"
+			}
+			return "\n\\begin{" + env + "}\n" + s + "\n\\end{" + env + "}\n"
+		},
+	},
+	TokenFigure: Tag{
+		Before: func(s string) string { return "\n\\begin{figure}[h]\n\\centering\n" + s },
+		After:  func(s string) string { return s + "\n\\end{figure}\n" },
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n\\caption{" + s },
+		After:      func(s string) string { return s + "}" },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "\n" + latexRule + "\n" },
+	},
+	TokenPageBreak: Tag{
+		Before: func(s string) string { return "\n\\newpage\n" },
+	},
+	// footnotes are set at their first reference by latexFootnotes, as the
+	// text given to \footnotetext at the end of the document would be set
+	// on the last page. Later references are marks.
+	TokenFootnoteRef: Tag{
+		NoPadAfter: true,
+		TrimInside: true,
+		Footnote: func(i int, s string) string {
+			if s == "" {
+				return fmt.Sprintf(`\footnotemark[%d]`, i)
+			}
+			return fmt.Sprintf(`\footnote[%d]{%s}`, i, s)
+		},
+	},
+	TokenFootnotes: Tag{},
+	TokenFootnote:  Tag{},
+}
+
+// latexRule is a horizontal rule across the text.
+const latexRule = `\noindent\rule{\linewidth}{0.4pt}`
+
+// latexReplacer escapes the characters latex treats specially.
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	// symbols inputenc has no definition for without textcomp or amssymb
+	`…`, `\ldots{}`,
+	`•`, `\textbullet{}`,
+	`†`, `\textdagger{}`,
+	`‡`, `\textdaggerdbl{}`,
+	`§`, `\S{}`,
+	`¶`, `\P{}`,
+	`©`, `\textcopyright{}`,
+	`®`, `\textregistered{}`,
+	`™`, `\texttrademark{}`,
+	`€`, `\texteuro{}`,
+	`°`, `\textdegree{}`,
+	`µ`, `\textmu{}`,
+	`±`, `\textpm{}`,
+	`×`, `\texttimes{}`,
+	`÷`, `\textdiv{}`,
+	`½`, `\textonehalf{}`,
+	`¼`, `\textonequarter{}`,
+	`¾`, `\textthreequarters{}`,
+	`←`, `\textleftarrow{}`,
+	`→`, `\textrightarrow{}`,
+	`↑`, `\textuparrow{}`,
+	`↓`, `\textdownarrow{}`,
+	`≤`, `\ensuremath{\leq}`,
+	`≥`, `\ensuremath{\geq}`,
+	`≠`, `\ensuremath{\neq}`,
+	`≈`, `\ensuremath{\approx}`,
+	`∞`, `\ensuremath{\infty}`,
+	`✓`, `\ensuremath{\checkmark}`,
+	`☐`, `\ensuremath{\square}`,
+	`☑`, `\ensuremath{\boxtimes}`,
+)
+
+func latexEscape(s string) string {
+	return latexReplacer.Replace(s)
+}
+
+// latexURL escapes the characters \href can't take as they are.
+func latexURL(href string) string {
+	return strings.NewReplacer(`\`, `\\`, `#`, `\#`, `%`, `\%`, `{`, `\{`, `}`, `\}`).Replace(href)
+}
+
+// latexLabel makes an anchor safe to use as a label. Labels are written to
+// the aux file, where characters other than ascii letters, digits and a few
+// marks break the build, so others are written as their code points.
+func latexLabel(anchor string) string {
+	var b strings.Builder
+	for _, r := range anchor {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == ':', r == '_':
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "+%x", r)
+		}
+	}
+
+	return b.String()
+}
+
+// latexListingsLanguages are the names listings knows languages by, for the
+// hints that name them. Code in other languages is verbatim, as listings
+// stops the build on a language it has no definition for.
+var latexListingsLanguages = map[string]string{
+	"ada":        "Ada",
+	"awk":        "Awk",
+	"bash":       "bash",
+	"c":          "C",
+	"c++":        "C++",
+	"cpp":        "C++",
+	"cobol":      "Cobol",
+	"csh":        "csh",
+	"delphi":     "Delphi",
+	"erlang":     "erlang",
+	"fortran":    "Fortran",
+	"haskell":    "Haskell",
+	"html":       "HTML",
+	"java":       "Java",
+	"ksh":        "ksh",
+	"latex":      "TeX",
+	"lisp":       "Lisp",
+	"make":       "make",
+	"makefile":   "make",
+	"matlab":     "Matlab",
+	"ocaml":      "Caml",
+	"octave":     "Octave",
+	"pascal":     "Pascal",
+	"perl":       "Perl",
+	"php":        "PHP",
+	"postscript": "PostScript",
+	"prolog":     "Prolog",
+	"python":     "Python",
+	"r":          "R",
+	"ruby":       "Ruby",
+	"scala":      "Scala",
+	"sh":         "sh",
+	"shell":      "sh",
+	"sql":        "SQL",
+	"tcl":        "tcl",
+	"tex":        "TeX",
+	"vbscript":   "VBScript",
+	"verilog":    "Verilog",
+	"vhdl":       "VHDL",
+	"xml":        "XML",
+	"xslt":       "XSLT",
+}
+
+// latexCodeBlockRegexp matches the code blocks written by TokenCode.
+var latexCodeBlockRegexp = regexp.MustCompile(`(?s)\n*\\begin\{(?:verbatim|lstlisting)\}(?:\[[^\]\n]*\])?\n(.*?)\n\\end\{(?:verbatim|lstlisting)\}\n*`)
+
+// latexCellCode turns the code blocks in a table cell into \texttt lines, as
+// verbatim environments can't be used in the argument of \multirow or
+// \multicolumn, or in the p columns of a tabular.
+func latexCellCode(s string) string {
+	return latexCodeBlockRegexp.ReplaceAllStringFunc(s, func(block string) string {
+		lines := strings.Split(latexCodeBlockRegexp.FindStringSubmatch(block)[1], "\n")
+		for i, line := range lines {
+			// \texttt collapses runs of spaces, so each is a control space
+			lines[i] = `\texttt{` + strings.Replace(latexEscape(line), " ", `\ `, -1) + "}"
+		}
+
+		return "\n\n" + strings.Join(lines, "\\newline\n") + "\n\n"
+	})
+}
+
+// latexList starts a list environment. Lists nested without an item of
+// their own, as docs allows, get an empty item to hang from.
+func latexList(env, s string) string {
+	if strings.HasPrefix(strings.TrimLeft(s, "\n"), `\begin{`) {
+		s = `\item[]` + "\n" + s
+	}
+
+	return "\n\\begin{" + env + "}\n" + s
+}
+
+// latexTitle turns the front matter into the title of the document.
+func latexTitle(n *Node, s string) string {
+	title := latexEscape(n.Title)
+	if n.Subtitle != "" {
+		title += `\\ \large ` + latexEscape(n.Subtitle)
+	}
+
+	return `\title{` + title + "}\n\\date{}\n\\maketitle\n"
+}
+
+// latexFootnotes moves the text of each footnote to its first reference and
+// removes the footnotes from the end of the document.
+func latexFootnotes(root *Node) {
+	footnotes := map[int]*Node{}
+
+	for i, child := range root.Children {
+		if child.Token == TokenFootnotes {
+			for _, footnote := range child.Children {
+				footnotes[footnote.FootnoteNum] = footnote
+			}
+			root.Children = append(root.Children[:i], root.Children[i+1:]...)
+			break
+		}
+	}
+
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		// footnotes can reference footnotes, so the moved text is visited too
+		if node.Token == TokenFootnoteRef {
+			if footnote, ok := footnotes[node.FootnoteNum]; ok {
+				for _, child := range footnote.Children {
+					node.append(child)
+				}
+				delete(footnotes, node.FootnoteNum)
+			}
+		}
+
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(root)
+}
+
+// latexDocument wraps the body in latexPreamble, so it compiles on its own.
+func latexDocument(body string) string {
+	return latexPreamble + body + "\n\\end{document}\n"
+}
+
+// latexTabular lays out a tabular with a paragraph column for each column of
+// the table, so that cells can wrap and hold more than one paragraph.
+// Merged cells use \multicolumn and \multirow.
+func latexTabular(rows [][]TableCell, headerRows int) string {
+	cells, ncols := placeCells(rows)
+	if ncols == 0 {
+		return ""
+	}
+
+	width := 0.9 / float64(ncols)
+	column := func(cs int) string {
+		return fmt.Sprintf(`p{%.2f\linewidth}`, width*float64(cs))
+	}
+
+	// the cell starting at each slot of the grid
+	starts := map[[2]int]*gridCell{}
+	for _, gc := range cells {
+		starts[[2]int{gc.row, gc.col}] = gc
+	}
+
+	var b strings.Builder
+
+	b.WriteString("\n\\begin{tabular}{|" + strings.Repeat(column(1)+"|", ncols) + "}\n\\hline\n")
+
+	for r := range rows {
+		row := []string{}
+
+		for c := 0; c < ncols; {
+			gc, ok := starts[[2]int{r, c}]
+			var content string
+
+			if ok {
+				content = strings.Replace(strings.TrimSpace(latexCellCode(gc.content)), "\n\n", "\\par ", -1)
+				if gc.rs > 1 {
+					content = fmt.Sprintf(`\multirow{%d}{*}{%s}`, gc.rs, content)
+				}
+			} else {
+				// covered by a merged cell from a row above
+				for _, above := range cells {
+					if above.col == c && above.row < r && above.row+above.rs > r {
+						gc = above
+						break
+					}
+				}
+			}
+
+			if gc.cs > 1 {
+				left := ""
+				if c == 0 {
+					left = "|"
+				}
+				content = fmt.Sprintf(`\multicolumn{%d}{%s%s|}{%s}`, gc.cs, left, column(gc.cs), content)
+			}
+
+			row = append(row, content)
+			c += gc.cs
+		}
+
+		b.WriteString(strings.Join(row, " & ") + ` \\` + "\n")
+
+		// rules stop at cells merged into the next row
+		var open []int
+		for c := 0; c < ncols; c++ {
+			covered := false
+			for _, gc := range cells {
+				if gc.row <= r && gc.row+gc.rs > r+1 && gc.col <= c && gc.col+gc.cs > c {
+					covered = true
+					break
+				}
+			}
+
+			if !covered {
+				open = append(open, c)
+			}
+		}
+
+		switch {
+		case len(open) == ncols:
+			b.WriteString("\\hline\n")
+			if r == headerRows-1 {
+				// a double rule under the header rows
+				b.WriteString("\\hline\n")
+			}
+		default:
+			for i := 0; i < len(open); {
+				j := i
+				for j+1 < len(open) && open[j+1] == open[j]+1 {
+					j++
+				}
+
+				fmt.Fprintf(&b, "\\cline{%d-%d}\n", open[i]+1, open[j]+1)
+				i = j + 1
+			}
+		}
+	}
+
+	b.WriteString("\\end{tabular}\n")
+
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package converters
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// equationPlaceholder stands in for equations whose text docs does not
+// export.
+const equationPlaceholder = "[equation]"
+
+// mathSymbols are the LaTeX commands for the symbols docs uses in equations.
+var mathSymbols = map[rune]string{
+	'α': `\alpha`, 'β': `\beta`, 'γ': `\gamma`, 'δ': `\delta`, 'ε': `\epsilon`,
+	'ζ': `\zeta`, 'η': `\eta`, 'θ': `\theta`, 'ι': `\iota`, 'κ': `\kappa`,
+	'λ': `\lambda`, 'μ': `\mu`, 'ν': `\nu`, 'ξ': `\xi`, 'π': `\pi`,
+	'ρ': `\rho`, 'σ': `\sigma`, 'τ': `\tau`, 'υ': `\upsilon`, 'φ': `\phi`,
+	'χ': `\chi`, 'ψ': `\psi`, 'ω': `\omega`,
+	'Γ': `\Gamma`, 'Δ': `\Delta`, 'Θ': `\Theta`, 'Λ': `\Lambda`, 'Ξ': `\Xi`,
+	'Π': `\Pi`, 'Σ': `\Sigma`, 'Φ': `\Phi`, 'Ψ': `\Psi`, 'Ω': `\Omega`,
+	'≤': `\leq`, '≥': `\geq`, '≠': `\neq`, '≈': `\approx`, '≡': `\equiv`,
+	'∼': `\sim`, '∝': `\propto`, '×': `\times`, '÷': `\div`, '±': `\pm`,
+	'∓': `\mp`, '·': `\cdot`, '∘': `\circ`, '∞': `\infty`, '∂': `\partial`,
+	'∇': `\nabla`, '∑': `\sum`, '∏': `\prod`, '∫': `\int`, '∮': `\oint`,
+	'√': `\sqrt`, '∈': `\in`, '∉': `\notin`, '⊂': `\subset`, '⊃': `\supset`,
+	'⊆': `\subseteq`, '⊇': `\supseteq`, '∪': `\cup`, '∩': `\cap`, '∅': `\emptyset`,
+	'∀': `\forall`, '∃': `\exists`, '¬': `\neg`, '∧': `\wedge`, '∨': `\vee`,
+	'→': `\rightarrow`, '←': `\leftarrow`, '↔': `\leftrightarrow`, '⇒': `\Rightarrow`,
+	'⇐': `\Leftarrow`, '⇔': `\Leftrightarrow`, '…': `\ldots`, '⋯': `\cdots`,
+	'°': `^\circ`, '′': `'`,
+	'#': `\#`, '$': `\$`, '%': `\%`, '&': `\&`, '_': `\_`, '{': `\{`, '}': `\}`,
+	'\\': `\backslash`,
+}
+
+// commandSpaceRegexp matches the space after a command that is only needed
+// before letters.
+var commandSpaceRegexp = regexp.MustCompile(`(\\[a-zA-Z]+) ([^a-zA-Z])`)
+
+// equationLatex reconstructs the LaTeX for an equation from the text runs
+// inside it. Superscript and subscript runs become exponents and indices.
+func equationLatex(runs []*docs.TextRun) string {
+	var b strings.Builder
+
+	for _, run := range runs {
+		var text strings.Builder
+
+		runes := []rune(strings.TrimRight(run.Content, "\n"))
+
+		for i, r := range runes {
+			// a tilde between two operands is similarity; elsewhere it is
+			// left as typed, like the carets of exponents
+			if r == '~' && isSimilarity(runes, i) {
+				text.WriteString(`\sim `)
+				continue
+			}
+
+			if sym, ok := mathSymbols[r]; ok {
+				text.WriteString(sym)
+				// commands swallow the letters that follow them
+				if last := sym[len(sym)-1]; last >= 'a' && last <= 'z' || last >= 'A' && last <= 'Z' {
+					text.WriteRune(' ')
+				}
+				continue
+			}
+
+			text.WriteRune(r)
+		}
+
+		content := strings.TrimSpace(text.String())
+		if content == "" {
+			continue
+		}
+
+		offset := ""
+		if run.TextStyle != nil {
+			offset = run.TextStyle.BaselineOffset
+		}
+
+		switch offset {
+		case "SUPERSCRIPT":
+			b.WriteString("^{" + content + "}")
+		case "SUBSCRIPT":
+			b.WriteString("_{" + content + "}")
+		default:
+			b.WriteString(text.String())
+		}
+	}
+
+	return strings.TrimSpace(commandSpaceRegexp.ReplaceAllString(b.String(), "$1$2"))
+}
+
+// isSimilarity reports whether the tilde at i of the runes stands between
+// two operands, ignoring spaces.
+func isSimilarity(runes []rune, i int) bool {
+	var before, after rune
+
+	for j := i - 1; j >= 0 && before == 0; j-- {
+		if !unicode.IsSpace(runes[j]) {
+			before = runes[j]
+		}
+	}
+
+	for j := i + 1; j < len(runes) && after == 0; j++ {
+		if !unicode.IsSpace(runes[j]) {
+			after = runes[j]
+		}
+	}
+
+	operand := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	return (operand(before) || strings.ContainsRune(")]}", before)) &&
+		(operand(after) || strings.ContainsRune("([{", after))
+}
+
+// parseEquation appends the equation at element i of the paragraph.
+// Equations alone in their paragraph are displayed as blocks.
+func parseEquation(para *docs.Paragraph, i int, node *Node) {
+	start, end := para.Elements[i].StartIndex, para.Elements[i].EndIndex
+	runs := []*docs.TextRun{}
+
+	for _, pelem := range para.Elements[i+1:] {
+		if pelem.StartIndex >= end {
+			break
+		}
+
+		if pelem.TextRun != nil {
+			runs = append(runs, pelem.TextRun)
+		}
+	}
+
+	latex := equationLatex(runs)
+	if latex == "" {
+		node.append(&Node{Token: TokenPlain, Content: equationPlaceholder})
+		return
+	}
+
+	token := Token(TokenMathBlock)
+
+	for _, pelem := range para.Elements {
+		if pelem.StartIndex >= start && pelem.StartIndex < end {
+			continue
+		}
+
+		if pelem.TextRun == nil || strings.TrimSpace(pelem.TextRun.Content) != "" {
+			token = TokenMath
+			break
+		}
+	}
+
+	node.append(&Node{Token: token}).append(&Node{Token: TokenPlain, Content: latex})
+}
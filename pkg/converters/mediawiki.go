@@ -0,0 +1,468 @@
+package converters
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+var mediaWiki = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		LeftPad:  true,
+		Escape:   mediaWikiEscape,
+	},
+	TokenBold: Tag{
+		Collapse:        true,
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "'''" + s },
+		After:           func(s string) string { return s + "'''" },
+	},
+	TokenItalic: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "''" + s },
+		After:           func(s string) string { return s + "''" },
+	},
+	TokenStrikethrough: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<s>" + s },
+		After:           func(s string) string { return s + "</s>" },
+	},
+	TokenUnderline: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<u>" + s },
+		After:           func(s string) string { return s + "</u>" },
+	},
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "<sup>" + s },
+		After:           func(s string) string { return s + "</sup>" },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "<sub>" + s },
+		After:           func(s string) string { return s + "</sub>" },
+	},
+	TokenParagraph: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n" + s },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// every list a line is in adds its marker to the line, so nested items
+	// get the markers of all of their lists. Blank lines would end the list,
+	// so items are on consecutive lines.
+	TokenUnorderedList: Tag{
+		NodeBefore: func(n *Node, s string) string { return mediaWikiList(n, "*", s) },
+	},
+	TokenUnorderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return " " + mediaWikiItem(s) },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenOrderedList: Tag{
+		NodeBefore: func(n *Node, s string) string { return mediaWikiList(n, "#", s) },
+	},
+	TokenOrderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			// # lists always count from 1, so items that continue the
+			// numbering of a list before them are html lists.
+			if n.BulletNesting == 0 && n.ListNumber > listPosition(n) {
+				attrs := fmt.Sprintf(`start="%d"`, n.ListNumber)
+				switch n.parent.ListType {
+				case "", "1":
+				case "01":
+					attrs += ` style="list-style-type: decimal-leading-zero"`
+				default:
+					attrs += fmt.Sprintf(" type=%q", n.parent.ListType)
+				}
+				return "<ol " + attrs + "><li>" + mediaWikiItem(s) + "</li></ol>"
+			}
+			return " " + mediaWikiItem(s)
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenCheckList: Tag{
+		NodeBefore: func(n *Node, s string) string { return mediaWikiList(n, "*", s) },
+	},
+	TokenCheckBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			if n.Checked {
+				return " ☑ " + mediaWikiItem(s)
+			}
+			return " ☐ " + mediaWikiItem(s)
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat: func(times int, s string) string {
+			// a single = is the page title, and headings stop at level 6
+			if times > 5 {
+				times = 5
+			}
+			marks := strings.Repeat("=", times+1)
+			return marks + " " + s + " " + marks
+		},
+		// mediawiki makes its own ids from the heading text
+		Anchor: func(anchor, s string) string {
+			return strings.Replace(s, "= ", fmt.Sprintf("= <span id=%q></span>", anchor), 1)
+		},
+	},
+	TokenTable: Tag{
+		Before: func(s string) string { return "\n{| class=\"wikitable\"\n" + s },
+		After:  func(s string) string { return s + "|}\n" },
+	},
+	TokenTableHead: Tag{},
+	TokenTableHeaderCell: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string { return mediaWikiCell("!", s, n.ColSpan, n.RowSpan) },
+	},
+	TokenTableCell: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string { return mediaWikiCell("|", s, n.ColSpan, n.RowSpan) },
+	},
+	TokenTableRow: Tag{
+		Before: func(s string) string { return "|-\n" + s },
+	},
+	TokenImage: Tag{
+		MapFile: mediaWikiImage,
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				s = strings.TrimRight(s, "\n")
+				if n.Language != "" {
+					return fmt.Sprintf("\n<syntaxhighlight lang=%q>\n", n.Language) + s + "\n</syntaxhighlight>\n"
+				}
+				// pre reads entities, so they are escaped too
+				return "\n<pre>\n" + html.EscapeString(s) + "\n</pre>\n"
+			}
+			// nowiki keeps the markup in code as it is, apart from entities
+			if mediaWikiEscape(s) != s {
+				return "<code><nowiki>" + html.EscapeString(s) + "</nowiki></code>"
+			}
+			return "<code>" + s + "</code>"
+		},
+	},
+	TokenLink: Tag{
+		LeftPad: true,
+		Link: func(href, s string) string {
+			if strings.HasPrefix(href, "#") {
+				return "[[" + href + "|" + s + "]]"
+			}
+			return "[" + strings.NewReplacer(" ", "%20", "]", "%5D").Replace(href) + " " + s + "]"
+		},
+	},
+	TokenTOC: Tag{
+		Before: func(s string) string { return "\n__TOC__\n" },
+	},
+	TokenTOCList:  Tag{},
+	TokenTOCEntry: Tag{},
+	TokenLineBreak: Tag{
+		NoPadAfter: true,
+		// a new line would end list items and table cells
+		Before: func(s string) string { return "<br />" },
+	},
+	TokenSubtitle: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n<p class=\"subtitle\">" + s },
+		After:      func(s string) string { return s + "</p>\n" },
+	},
+	TokenFrontMatter: Tag{
+		NodeBefore: mediaWikiTitle,
+	},
+	TokenBlockquote: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n<blockquote>\n" + s },
+		After:      func(s string) string { return s + "\n</blockquote>\n" },
+	},
+	TokenAdmonition: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			return fmt.Sprintf("\n<div class=\"admonition %s\">\n'''%s:''' ", n.Admonition, admonitionTitles[n.Admonition]) + s
+		},
+		After: func(s string) string { return s + "\n</div>\n" },
+	},
+	TokenHeader: Tag{
+		After: func(s string) string { return s + "\n----\n" },
+	},
+	TokenFooter: Tag{
+		Before: func(s string) string { return "\n----\n" + s },
+	},
+	TokenInsertion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<ins>" + s },
+		After:           func(s string) string { return s + "</ins>" },
+	},
+	TokenDeletion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "<del>" + s },
+		After:           func(s string) string { return s + "</del>" },
+	},
+	// the Math extension renders these
+	TokenMath: Tag{
+		NoEscape:   true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return "<math>" + s },
+		After:      func(s string) string { return s + "</math>" },
+	},
+	TokenMathBlock: Tag{
+		NoEscape: true,
+		Before:   func(s string) string { return "<math display=\"block\">" + s },
+		After:    func(s string) string { return s + "</math>" },
+	},
+	TokenFloat: Tag{
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			s = strings.TrimSpace(s)
+			if n.Float != "" {
+				s = strings.Replace(s, "]]", "|"+n.Float+"]]", 1)
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenFigure: Tag{
+		Before: mediaWikiFigure,
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n" + s },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "\n----\n" },
+	},
+	TokenPageBreak: Tag{
+		Before: func(s string) string { return "\n" + pageBreakDiv + "\n" },
+	},
+	// footnotes are list-defined references for the Cite extension, so
+	// their text can stay at the end of the document.
+	TokenFootnoteRef: Tag{
+		NoPadAfter: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf(`<ref name="fn-%d" />`, i) },
+	},
+	TokenFootnotes: Tag{
+		Before: func(s string) string { return "\n<references>\n" + s },
+		After:  func(s string) string { return s + "</references>\n" },
+	},
+	TokenFootnote: Tag{
+		TrimInside: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf("<ref name=\"fn-%d\">%s</ref>\n", i, s) },
+	},
+}
+
+// mediaWikiEscape replaces the characters mediawiki reads as markup with
+// character references. Quotes and tildes only make markup in runs, so single
+// ones are left alone.
+func mediaWikiEscape(s string) string {
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '&', '<', '>', '[', ']', '{', '}', '|':
+			fmt.Fprintf(&b, "&#%d;", r)
+		case '\'', '~':
+			if (i > 0 && runes[i-1] == r) || (i+1 < len(runes) && runes[i+1] == r) {
+				fmt.Fprintf(&b, "&#%d;", r)
+			} else {
+				b.WriteRune(r)
+			}
+		case '*', '#', ':', ';', '=':
+			// these only make markup at the start of a line
+			if i == 0 || runes[i-1] == '\n' {
+				fmt.Fprintf(&b, "&#%d;", r)
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// mediaWikiList adds the marker of a list to the lines that start its items.
+// Lines in code blocks are left alone, as the block belongs to the item it
+// starts in. Items of a list continued as html get no marker, and the items
+// nested under them are indented with : instead.
+func mediaWikiList(n *Node, marker, s string) string {
+	if n.BulletNesting == 0 && mediaWikiContinued(n) {
+		marker = ":"
+		if itemBullet(n).BulletNesting == 0 {
+			marker = ""
+		}
+	}
+
+	var code bool
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case code:
+			code = !mediaWikiCodeEnd(line)
+		case line != "":
+			lines[i] = marker + line
+			code = mediaWikiCodeStart(line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// mediaWikiItem puts the content of a list item on one line, as a new line
+// ends the item. Lines are joined with line breaks, and code blocks start on
+// the item's line, where their own lines don't end it.
+func mediaWikiItem(s string) string {
+	var b strings.Builder
+	var code, joined bool
+
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		switch {
+		case code:
+			b.WriteString("\n" + line)
+			code = !mediaWikiCodeEnd(line)
+			joined = !code
+		case line == "":
+		case mediaWikiCodeStart(line):
+			b.WriteString(line)
+			code = true
+		default:
+			if b.Len() > 0 && !joined {
+				b.WriteString("<br />")
+			}
+			b.WriteString(line)
+			joined = false
+		}
+	}
+
+	return b.String()
+}
+
+// mediaWikiCodeStart returns whether a line ends with the start of a code
+// block.
+func mediaWikiCodeStart(line string) bool {
+	return (strings.Contains(line, "<syntaxhighlight ") && !strings.HasSuffix(line, "</syntaxhighlight>")) ||
+		(strings.HasSuffix(line, "<pre>"))
+}
+
+// mediaWikiCodeEnd returns whether a line ends a code block.
+func mediaWikiCodeEnd(line string) bool {
+	return line == "</syntaxhighlight>" || line == "</pre>"
+}
+
+// mediaWikiContinued returns whether the top level item of a list, or the
+// item its nested items are under, continues the numbering of a list before
+// it.
+func mediaWikiContinued(list *Node) bool {
+	if list.parent == nil {
+		return false
+	}
+
+	siblings := list.parent.Children
+
+	i := len(siblings) - 1
+	for i >= 0 && siblings[i] != list {
+		i--
+	}
+
+	for ; i >= 0; i-- {
+		bullet := itemBullet(siblings[i])
+		if bullet == nil {
+			return false
+		}
+
+		if bullet.BulletNesting == 0 {
+			return bullet.Token == TokenOrderedBullet && bullet.ListNumber > listPosition(bullet)
+		}
+	}
+
+	return false
+}
+
+// mediaWikiCell starts a table cell on a line of its own, with its span.
+func mediaWikiCell(mark, s string, colspan, rowspan int64) string {
+	if attrs := spanAttrs(colspan, rowspan); attrs != "" {
+		return mark + attrs + " | " + s + "\n"
+	}
+
+	return mark + " " + s + "\n"
+}
+
+// mediaWikiImage returns the file link for an image, with its size and alt
+// text. Uploaded files have no directories, so it is linked by its base
+// name.
+func mediaWikiImage(file downloader.ManifestFile) string {
+	res := fmt.Sprintf("[[File:%s|%dx%dpx", path.Base(file.Filename), file.Width, file.Height)
+
+	if file.Description != "" {
+		res += "|alt=" + mediaWikiEscape(strings.Replace(file.Description, "\n", " ", -1))
+	}
+
+	return res + "]]"
+}
+
+// mediaWikiFigure makes a thumbnail of the image of a figure, with its
+// caption.
+func mediaWikiFigure(s string) string {
+	s = strings.TrimSpace(s)
+
+	idx := strings.Index(s, "]]")
+	if idx < 0 {
+		return "\n" + s + "\n"
+	}
+
+	caption := strings.Replace(strings.TrimSpace(s[idx+2:]), "\n", " ", -1)
+	if caption == "" {
+		return "\n" + s + "\n"
+	}
+
+	return "\n" + s[:idx] + "|thumb|" + caption + "]]\n"
+}
+
+// mediaWikiTitle sets the title of the page from the front matter, and
+// writes the subtitle under it.
+func mediaWikiTitle(n *Node, s string) string {
+	var res string
+
+	if n.Title != "" {
+		res += "{{DISPLAYTITLE:" + mediaWikiEscape(n.Title) + "}}\n"
+	}
+
+	if n.Subtitle != "" {
+		res += "<p class=\"subtitle\">" + mediaWikiEscape(n.Subtitle) + "</p>\n"
+	}
+
+	return res
+}
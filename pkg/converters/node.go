@@ -1,15 +1,30 @@
 package converters
 
 type Node struct {
-	Url           string
-	ObjectId      string
-	Repeat        int
-	BulletNesting int64
-	ListNumber    int
-	Token         Token
-	parent        *Node
-	Children      []*Node
-	Content       string
+	Url             string
+	Anchor          string
+	ObjectId        string
+	Repeat          int
+	BulletNesting   int64
+	ListNumber      int
+	ListType        string
+	Checked         bool
+	ColSpan         int64
+	RowSpan         int64
+	Columns         int64
+	Language        string
+	Float           string
+	Centered        bool
+	Admonition      string
+	AdmonitionStyle AdmonitionStyle
+	Title           string
+	Subtitle        string
+	FootnoteNum     int
+	FootnoteRef     int
+	Token           Token
+	parent          *Node
+	Children        []*Node
+	Content         string
 }
 
 func (n *Node) append(node *Node) *Node {
@@ -18,3 +33,92 @@ func (n *Node) append(node *Node) *Node {
 
 	return node
 }
+
+func firstPlain(n *Node) *Node {
+	for _, child := range n.Children {
+		if child.Token == TokenPlain {
+			return child
+		}
+
+		if plain := firstPlain(child); plain != nil {
+			return plain
+		}
+	}
+
+	return nil
+}
+
+func isListToken(t Token) bool {
+	return t == TokenUnorderedList || t == TokenOrderedList || t == TokenCheckList
+}
+
+func isBulletToken(t Token) bool {
+	return t == TokenUnorderedBullet || t == TokenOrderedBullet || t == TokenCheckBullet
+}
+
+// itemBullet returns the bullet at the end of a list item's chain of list
+// nodes, or nil if n is not a list item.
+func itemBullet(n *Node) *Node {
+	for isListToken(n.Token) {
+		var next *Node
+
+		for _, child := range n.Children {
+			if isListToken(child.Token) || isBulletToken(child.Token) {
+				next = child
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		n = next
+	}
+
+	if isBulletToken(n.Token) {
+		return n
+	}
+
+	return nil
+}
+
+// listPosition returns the position of a bullet in its list, starting at 1.
+// Every list item is a chain of list nodes of its own, so the items before
+// it are found by walking back over the chains before its own, until one
+// that is less nested or in another kind of list.
+func listPosition(bullet *Node) int {
+	top := bullet
+	for top.parent != nil && isListToken(top.parent.Token) {
+		top = top.parent
+	}
+
+	if top.parent == nil {
+		return 1
+	}
+
+	siblings := top.parent.Children
+
+	i := len(siblings) - 1
+	for i >= 0 && siblings[i] != top {
+		i--
+	}
+
+	pos := 1
+
+	for i--; i >= 0; i-- {
+		prev := itemBullet(siblings[i])
+		if prev == nil || prev.BulletNesting < bullet.BulletNesting {
+			break
+		}
+
+		if prev.BulletNesting == bullet.BulletNesting {
+			if prev.Token != bullet.Token || prev.parent.ListType != bullet.parent.ListType {
+				break
+			}
+
+			pos++
+		}
+	}
+
+	return pos
+}
@@ -0,0 +1,160 @@
+package converters
+
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultCodeFonts are the fonts that mark text as code when Options.CodeFonts
+// is empty.
+var DefaultCodeFonts = []string{
+	"Consolas",
+	"Courier New",
+	"Roboto Mono",
+	"Source Code Pro",
+	"JetBrains Mono",
+	"Inconsolata",
+	"Ubuntu Mono",
+	"Fira Code",
+	"IBM Plex Mono",
+}
+
+// PageBreakMode is how page and section breaks are output.
+type PageBreakMode string
+
+const (
+	// PageBreaksIgnore drops page and section breaks.
+	PageBreaksIgnore PageBreakMode = ""
+	// PageBreaksDiv emits a div that breaks the page when printed.
+	PageBreaksDiv PageBreakMode = "div"
+	// PageBreaksSplit splits the document into pages at each break. See
+	// ConvertPages.
+	PageBreaksSplit PageBreakMode = "split"
+)
+
+// SuggestionMode is how suggested changes are exported.
+type SuggestionMode string
+
+const (
+	// SuggestionsInline exports suggestions as docs returns them, mixed in
+	// with the text.
+	SuggestionsInline SuggestionMode = ""
+	// SuggestionsAccept exports the document as if all suggestions were
+	// accepted.
+	SuggestionsAccept SuggestionMode = "accept"
+	// SuggestionsReject exports the document as if all suggestions were
+	// rejected.
+	SuggestionsReject SuggestionMode = "reject"
+	// SuggestionsMark marks suggested insertions and deletions.
+	SuggestionsMark SuggestionMode = "mark"
+)
+
+// ParseSuggestionMode returns the suggestion mode by name. An empty name is
+// SuggestionsInline.
+func ParseSuggestionMode(name string) (SuggestionMode, error) {
+	switch mode := SuggestionMode(name); mode {
+	case SuggestionsInline, SuggestionsAccept, SuggestionsReject, SuggestionsMark:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid suggestion mode %q; must be accept, reject or mark", name)
+	}
+}
+
+// ViewMode returns the SuggestionsViewMode to fetch documents with, so that
+// docs applies accepted or rejected suggestions itself.
+func (m SuggestionMode) ViewMode() string {
+	switch m {
+	case SuggestionsAccept:
+		return "PREVIEW_SUGGESTIONS_ACCEPTED"
+	case SuggestionsReject:
+		return "PREVIEW_WITHOUT_SUGGESTIONS"
+	case SuggestionsMark:
+		return "SUGGESTIONS_INLINE"
+	default:
+		return ""
+	}
+}
+
+// TitleMode is how paragraphs in the title and subtitle styles are output.
+type TitleMode string
+
+const (
+	// TitlesPlain outputs titles and subtitles as ordinary paragraphs.
+	TitlesPlain TitleMode = ""
+	// TitlesHeading outputs the title as a level 1 heading, and the subtitle
+	// as a paragraph with the subtitle class. If the document has a title,
+	// its headings move down a level, so the title is the only level 1
+	// heading.
+	TitlesHeading TitleMode = "heading"
+	// TitlesFrontMatter moves the title and subtitle into the title and
+	// subtitle fields of YAML front matter at the top of the output.
+	TitlesFrontMatter TitleMode = "front-matter"
+)
+
+// DefaultQuoteIndent is the left indent, in points, that makes a paragraph
+// a block quote when Options.QuoteIndent is zero. It is one step of the
+// indent buttons in docs.
+const DefaultQuoteIndent = 36
+
+// AdmonitionStyle is the markdown syntax callouts are written in.
+type AdmonitionStyle string
+
+const (
+	// AdmonitionsNone leaves callouts as tables.
+	AdmonitionsNone AdmonitionStyle = ""
+	// AdmonitionsGitHub writes callouts as github alerts: > [!NOTE]
+	AdmonitionsGitHub AdmonitionStyle = "github"
+	// AdmonitionsMkDocs writes callouts as MkDocs admonitions: !!! note
+	AdmonitionsMkDocs AdmonitionStyle = "mkdocs"
+	// AdmonitionsDocusaurus writes callouts as Docusaurus admonitions: :::note
+	AdmonitionsDocusaurus AdmonitionStyle = "docusaurus"
+)
+
+// Options change the output of the conversion. The zero value is the default
+// behavior.
+type Options struct {
+	// Anchors emits explicit anchors for headings in formats that normally
+	// rely on the renderer to generate them from the heading text, such as
+	// markdown.
+	Anchors bool
+	// TOC inserts a table of contents at the top of the document, if the
+	// document does not already have one.
+	TOC bool
+	// TableHeader treats the first row of every table as its header.
+	TableHeader bool
+	// CodeFonts are the fonts that mark text as code. If empty,
+	// DefaultCodeFonts is used.
+	CodeFonts []string
+	// PageBreaks is how page and section breaks are output.
+	PageBreaks PageBreakMode
+	// Figures turns images followed by a caption into figures. Captions are
+	// paragraphs in italics, or starting with "Figure" or "Caption".
+	Figures bool
+	// Blockquotes turns paragraphs indented by at least QuoteIndent, that are
+	// not in a list, into block quotes.
+	Blockquotes bool
+	// QuoteIndent is the left indent in points that makes a paragraph a block
+	// quote. Zero is DefaultQuoteIndent.
+	QuoteIndent float64
+	// Admonitions turns callouts, tables of a single shaded cell or a cell
+	// starting with an emoji label, into admonitions in this style. html
+	// always uses MkDocs style markup.
+	Admonitions AdmonitionStyle
+	// Titles is how paragraphs in the title and subtitle styles are output.
+	Titles TitleMode
+	// HeadingOffset is added to the level of every heading, for documents that
+	// are embedded in a larger page. Levels stop at 6.
+	HeadingOffset int
+	// Standalone wraps latex output in a preamble, so it compiles on its own
+	// instead of being included in another document. Other formats are left
+	// as they are.
+	Standalone bool
+	// HeadersFooters includes the document's headers and footers at the start
+	// and end of the output.
+	HeadersFooters bool
+	// Suggestions is how suggested changes are exported.
+	Suggestions SuggestionMode
+	// Warnings, if set, receives a line for every paragraph element that
+	// cannot be converted, instead of dropping it silently.
+	Warnings io.Writer `json:"-"`
+}
@@ -0,0 +1,415 @@
+package converters
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+// orgIndent is the indent of each level of nested lists.
+const orgIndent = "   "
+
+var org = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		LeftPad:  true,
+		Escape:   orgEscape,
+	},
+	TokenBold: Tag{
+		Collapse:        true,
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "*" + s },
+		After:           func(s string) string { return s + "*" },
+	},
+	TokenItalic: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "/" + s },
+		After:           func(s string) string { return s + "/" },
+	},
+	TokenStrikethrough: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "+" + s },
+		After:           func(s string) string { return s + "+" },
+	},
+	TokenUnderline: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "_" + s },
+		After:           func(s string) string { return s + "_" },
+	},
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "^{" + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return "_{" + s },
+		After:           func(s string) string { return s + "}" },
+	},
+	TokenParagraph: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n" + s },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// nesting is in the indent made by Repeat, so lists need no markup of
+	// their own.
+	TokenUnorderedList: Tag{},
+	TokenUnorderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          orgNesting,
+		Before:          func(s string) string { return "\n" + orgListItem(s, "- ", "") },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenOrderedList: Tag{},
+	TokenOrderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          orgNesting,
+		NodeBefore: func(n *Node, s string) string {
+			return "\n" + orgListItem(s, fmt.Sprintf("%d. ", n.ListNumber), "")
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenCheckList: Tag{},
+	TokenCheckBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          orgNesting,
+		NodeBefore: func(n *Node, s string) string {
+			if n.Checked {
+				return "\n" + orgListItem(s, "- ", "[X] ")
+			}
+			return "\n" + orgListItem(s, "- ", "[ ] ")
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return strings.Repeat("*", times) + " " + s },
+		// links to #anchor find headings by their CUSTOM_ID
+		Anchor: func(anchor, s string) string {
+			return s + "\n:PROPERTIES:\n:CUSTOM_ID: " + anchor + "\n:END:"
+		},
+	},
+	TokenTable: Tag{
+		GridTable: orgTable,
+	},
+	// tables are laid out by GridTable
+	TokenTableHead:       Tag{},
+	TokenTableHeaderCell: Tag{},
+	TokenTableCell:       Tag{},
+	TokenTableRow:        Tag{},
+	TokenImage: Tag{
+		MapFile: orgImage,
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				begin := "\n#+BEGIN_SRC"
+				if n.Language != "" {
+					begin += " " + n.Language
+				}
+				return begin + "\n" + orgEscapeCode(strings.TrimRight(s, "\n")) + "\n#+END_SRC\n"
+			}
+			// verbatim can hold the tilde that would end code
+			if strings.Contains(s, "~") && !strings.Contains(s, "=") {
+				return "=" + s + "="
+			}
+			return "~" + s + "~"
+		},
+	},
+	TokenLink: Tag{
+		LeftPad: true,
+		Link: func(href, s string) string {
+			return "[[" + orgURL(href) + "][" + strings.Replace(s, "]]", "] ]", -1) + "]]"
+		},
+	},
+	TokenTOC: Tag{
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenTOCList: Tag{
+		Before: func(s string) string { return "\n" + s },
+	},
+	TokenTOCEntry: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "- " + indentLines(s, "  ") },
+		After:      func(s string) string { return s + "\n" },
+	},
+	TokenLineBreak: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return "\\\\\n" },
+	},
+	TokenSubtitle: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n#+SUBTITLE: " + strings.Replace(s, "\n", " ", -1) },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// front matter becomes the export keywords of the document
+	TokenFrontMatter: Tag{
+		NodeBefore: orgKeywords,
+	},
+	TokenBlockquote: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n#+BEGIN_QUOTE\n" + s },
+		After:      func(s string) string { return s + "\n#+END_QUOTE\n" },
+	},
+	// special blocks are exported as a div with the block's name as its class
+	TokenAdmonition: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			return "\n#+BEGIN_" + n.Admonition + "\n" + s + "\n#+END_" + n.Admonition + "\n"
+		},
+	},
+	TokenHeader: Tag{
+		After: func(s string) string { return s + "\n-----\n" },
+	},
+	TokenFooter: Tag{
+		Before: func(s string) string { return "\n-----\n" + s },
+	},
+	// emphasis can't start right after other emphasis ends, so a suggested
+	// replacement is set apart from the text it replaces.
+	TokenInsertion: Tag{
+		LeftPad:         true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "_" + s },
+		After:           func(s string) string { return s + "_" },
+	},
+	TokenDeletion: Tag{
+		LeftPad:         true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "+" + s },
+		After:           func(s string) string { return s + "+" },
+	},
+	TokenMath: Tag{
+		NoEscape:   true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return `\(` + s },
+		After:      func(s string) string { return s + `\)` },
+	},
+	TokenMathBlock: Tag{
+		NoEscape: true,
+		Before:   func(s string) string { return "\\[\n" + s },
+		After:    func(s string) string { return s + "\n\\]" },
+	},
+	TokenFloat: Tag{
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			s = strings.TrimSpace(s)
+			if n.Float != "" {
+				s = strings.Replace(s, "\n[[file:", " :align "+n.Float+"\n[[file:", 1)
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenFigure: Tag{
+		Before: orgFigure,
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		// keywords can't span lines
+		Before: func(s string) string { return "\n#+CAPTION: " + strings.Replace(s, "\n", " ", -1) },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "\n-----\n" },
+	},
+	TokenPageBreak: Tag{
+		Before: func(s string) string { return "\n#+HTML: " + pageBreakDiv + "\n#+LATEX: \\newpage\n" },
+	},
+	TokenFootnoteRef: Tag{
+		NoPadAfter: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf("[fn:%d]", i) },
+	},
+	// org exports the footnote section itself, and leaves its heading out
+	TokenFootnotes: Tag{
+		Repeat: func(times int, s string) string { return "\n" + strings.Repeat("*", times) + " Footnotes\n" + s },
+	},
+	TokenFootnote: Tag{
+		TrimInside: true,
+		Footnote:   func(i int, s string) string { return fmt.Sprintf("\n[fn:%d] %s\n", i, s) },
+	},
+}
+
+// orgEntities are the entities org exports as the emphasis marks.
+var orgEntities = map[rune]string{
+	'*': `\ast{}`,
+	'/': `\slash{}`,
+	'_': `\under{}`,
+	'=': `\equal{}`,
+	'~': `\tilde{}`,
+	'+': `\plus{}`,
+}
+
+// orgEscape keeps emphasis marks from starting emphasis, by replacing them
+// with their entities. Emphasis only starts after a space or opening
+// punctuation and before something else, so other marks are left alone.
+func orgEscape(s string) string {
+	runes := []rune(s)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if entity, ok := orgEntities[r]; ok {
+			opens := i == 0 || strings.ContainsRune(" \t\n-({'\"", runes[i-1])
+			if opens && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+				b.WriteString(entity)
+				continue
+			}
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// orgEscapeCode escapes the lines of a block that org would read as
+// headings or keywords with a comma, which org removes again.
+func orgEscapeCode(s string) string {
+	lines := strings.Split(s, "\n")
+
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t,")
+		if strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "#+") {
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			lines[i] = line[:indent] + "," + line[indent:]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// orgURL escapes the brackets and spaces that would end a link early.
+func orgURL(href string) string {
+	return strings.NewReplacer("[", "%5B", "]", "%5D", " ", "%20").Replace(href)
+}
+
+// orgNesting indents a list item to its nesting level.
+func orgNesting(nesting int, s string) string {
+	indent := strings.Repeat(orgIndent, nesting)
+	return indent + indentLines(s, indent)
+}
+
+// orgListItem puts the marker and checkbox of a list item after its indent,
+// with the body lined up after the marker.
+func orgListItem(s, marker, checkbox string) string {
+	indent := len(s) - len(strings.TrimLeft(s, " "))
+	return s[:indent] + marker + checkbox + indentLines(s[indent:], strings.Repeat(" ", len(marker)))
+}
+
+// orgImage links to an image, with its size and alt text in the attributes
+// for html export. Attributes go on a line of their own before the link, so
+// the image is set apart from any text around it.
+func orgImage(file downloader.ManifestFile) string {
+	attrs := fmt.Sprintf("#+ATTR_HTML: :width %d :height %d", file.Width, file.Height)
+
+	if file.Description != "" {
+		attrs += " :alt " + strings.Replace(file.Description, "\n", " ", -1)
+	}
+
+	return "\n" + attrs + "\n[[file:" + orgURL(file.Filename) + "]]\n"
+}
+
+// orgFigure moves the caption of a figure above its image, where keywords
+// go.
+func orgFigure(s string) string {
+	idx := strings.Index(s, "\n#+CAPTION:")
+	if idx < 0 {
+		return "\n" + strings.TrimSpace(s) + "\n"
+	}
+
+	return s[idx:] + "\n" + strings.TrimSpace(s[:idx]) + "\n"
+}
+
+// orgKeywords turns the front matter into the keywords org exports as the
+// document's title and subtitle.
+func orgKeywords(n *Node, s string) string {
+	var res string
+
+	if n.Title != "" {
+		res += "#+TITLE: " + n.Title + "\n"
+	}
+
+	if n.Subtitle != "" {
+		res += "#+SUBTITLE: " + n.Subtitle + "\n"
+	}
+
+	return res
+}
+
+// orgTable lays out a table with the header rows set apart by a rule. Org
+// tables have neither merged cells nor cells of more than one line, so the
+// slots covered by merged cells are left empty and lines are joined.
+func orgTable(rows [][]TableCell, headerRows int) string {
+	cells, ncols := placeCells(rows)
+	if ncols == 0 {
+		return ""
+	}
+
+	grid := make([][]string, len(rows))
+	for i := range grid {
+		grid[i] = make([]string, ncols)
+	}
+
+	widths := make([]int, ncols)
+
+	for _, gc := range cells {
+		content := strings.Replace(gc.content, "\\\\\n", "\n", -1)
+		content = strings.Join(strings.Fields(content), " ")
+		content = strings.Replace(content, "|", `\vert{}`, -1)
+
+		grid[gc.row][gc.col] = content
+		if l := displayWidth(content); l > widths[gc.col] {
+			widths[gc.col] = l
+		}
+	}
+
+	// org reads the rows above the first rule as the header, so tables
+	// without header rows still get one under their first row.
+	hline := headerRows - 1
+	if hline < 0 {
+		hline = 0
+	}
+
+	var b strings.Builder
+
+	for r, row := range grid {
+		b.WriteString("|")
+		for c, cell := range row {
+			b.WriteString(" " + cell + strings.Repeat(" ", widths[c]-displayWidth(cell)) + " |")
+		}
+		b.WriteString("\n")
+
+		if r == hline && r+1 < len(grid) {
+			sep := make([]string, ncols)
+			for c, width := range widths {
+				sep[c] = strings.Repeat("-", width+2)
+			}
+			b.WriteString("|" + strings.Join(sep, "+") + "|\n")
+		}
+	}
+
+	return "\n" + b.String()
+}
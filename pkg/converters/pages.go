@@ -0,0 +1,123 @@
+package converters
+
+// splitPages splits the document at its page breaks. Breaks are removed from
+// the tree; the top-level element holding a break ends its page. Content
+// after the last break is on the last page, and each page gets the footnotes
+// it references.
+func splitPages(root *Node) []*Node {
+	pages := []*Node{{}}
+
+	var footnotes *Node
+
+	for _, child := range root.Children {
+		if child.Token == TokenFootnotes {
+			footnotes = child
+			continue
+		}
+
+		page := pages[len(pages)-1]
+
+		if child.Token != TokenPageBreak {
+			page.append(child)
+
+			if !removePageBreaks(child) {
+				continue
+			}
+		}
+
+		// breaks in a row do not make empty pages
+		if len(page.Children) > 0 {
+			pages = append(pages, &Node{})
+		}
+	}
+
+	if len(pages) > 1 && len(pages[len(pages)-1].Children) == 0 {
+		pages = pages[:len(pages)-1]
+	}
+
+	if footnotes != nil {
+		for _, page := range pages {
+			addPageFootnotes(page, footnotes)
+		}
+	}
+
+	return pages
+}
+
+// addPageFootnotes adds the footnotes referenced on the page to it, in the
+// order of their first reference, before any footers. Footnotes referenced on
+// more than one page are copied to each of them, and references are counted
+// again from the start of the page, so their ids are unique on the page.
+func addPageFootnotes(page, footnotes *Node) {
+	byNum := map[int]*Node{}
+	for _, footnote := range footnotes.Children {
+		byNum[footnote.FootnoteNum] = footnote
+	}
+
+	pageFootnotes := &Node{Token: TokenFootnotes, Repeat: footnotes.Repeat}
+	copies := map[int]*Node{}
+
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		if node.Token == TokenFootnoteRef {
+			footnote, ok := copies[node.FootnoteNum]
+			if !ok {
+				orig, ok := byNum[node.FootnoteNum]
+				if !ok {
+					return
+				}
+
+				copied := *orig
+				copied.FootnoteRef = 0
+				footnote = pageFootnotes.append(&copied)
+				copies[node.FootnoteNum] = footnote
+			}
+
+			footnote.FootnoteRef++
+			node.FootnoteRef = footnote.FootnoteRef
+		}
+
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+
+	visit(page)
+
+	if len(pageFootnotes.Children) == 0 {
+		return
+	}
+
+	i := len(page.Children)
+	for i > 0 && page.Children[i-1].Token == TokenFooter {
+		i--
+	}
+
+	pageFootnotes.parent = page
+	page.Children = append(page.Children[:i], append([]*Node{pageFootnotes}, page.Children[i:]...)...)
+}
+
+// removePageBreaks removes the page breaks under the node, and reports
+// whether there were any.
+func removePageBreaks(node *Node) bool {
+	var found bool
+
+	children := []*Node{}
+
+	for _, child := range node.Children {
+		if child.Token == TokenPageBreak {
+			found = true
+			continue
+		}
+
+		if removePageBreaks(child) {
+			found = true
+		}
+
+		children = append(children, child)
+	}
+
+	node.Children = children
+
+	return found
+}
@@ -2,25 +2,76 @@ package converters
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/erikh/gdocs-export/pkg/downloader"
 	"google.golang.org/api/docs/v1"
 )
 
 type parser struct {
-	doc       *docs.Document
-	manifest  downloader.Manifest
-	bulletMap map[string]map[int64]int
+	doc          *docs.Document
+	manifest     downloader.Manifest
+	bulletMap    map[string]map[int64]int
+	footnotes    []string
+	footnoteMap  map[string]int
+	footnoteRefs map[string]int
+	slugs        slugger
+	anchors      map[*docs.Paragraph]string
+	headingMap   map[string]string
+	headings     []heading
+	hasTOC       bool
+	frontMatter  *Node
+	titleShift   int
+	codeFonts    map[string]bool
+	hint         languageHint
+	opts         Options
 }
 
-func Parse(doc *docs.Document, manifest downloader.Manifest) (*Node, error) {
+func Parse(doc *docs.Document, manifest downloader.Manifest, opts Options) (*Node, error) {
 	origNode := &Node{}
 
 	parser := &parser{
-		doc:       doc,
-		manifest:  manifest,
-		bulletMap: map[string]map[int64]int{},
+		doc:          doc,
+		manifest:     manifest,
+		bulletMap:    map[string]map[int64]int{},
+		footnoteMap:  map[string]int{},
+		footnoteRefs: map[string]int{},
+		slugs:        slugger{},
+		anchors:      map[*docs.Paragraph]string{},
+		headingMap:   map[string]string{},
+		frontMatter:  &Node{Token: TokenFrontMatter},
+		codeFonts:    map[string]bool{},
+		opts:         opts,
+	}
+
+	codeFonts := opts.CodeFonts
+	if len(codeFonts) == 0 {
+		codeFonts = DefaultCodeFonts
+	}
+
+	for _, font := range codeFonts {
+		parser.codeFonts[strings.ToLower(font)] = true
+	}
+
+	// the title is the only top level heading, so the document's own
+	// headings move down a level under it.
+	if opts.Titles == TitlesHeading && hasTitle(doc.Body.Content) {
+		parser.titleShift = 1
+	}
+
+	parser.collectHeadings(doc.Body.Content)
+
+	if opts.HeadersFooters {
+		if err := parser.parseHeaders(origNode); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TOC && !parser.hasTOC {
+		parser.parseTOC(origNode)
 	}
 
 	for _, elem := range doc.Body.Content {
@@ -29,6 +80,24 @@ func Parse(doc *docs.Document, manifest downloader.Manifest) (*Node, error) {
 		}
 	}
 
+	if opts.Figures {
+		parseFigures(origNode)
+	}
+
+	if opts.HeadersFooters {
+		if err := parser.parseFooters(origNode); err != nil {
+			return nil, err
+		}
+	}
+
+	// footnotes come last, as headers, footers and other footnotes can
+	// reference them
+	if err := parser.parseFootnotes(origNode); err != nil {
+		return nil, err
+	}
+
+	parser.prependFrontMatter(origNode)
+
 	if os.Getenv("DUMP_PARSE_TREE") != "" {
 		enc := json.NewEncoder(os.Stderr)
 		enc.SetIndent("", "  ")
@@ -41,19 +110,52 @@ func Parse(doc *docs.Document, manifest downloader.Manifest) (*Node, error) {
 func (p *parser) parseElement(elem *docs.StructuralElement, origNode *Node) error {
 	node := origNode
 
+	// every document starts with a section break, which breaks nothing.
+	if elem.SectionBreak != nil && elem.StartIndex > 0 && p.opts.PageBreaks != PageBreaksIgnore {
+		node.append(&Node{Token: TokenPageBreak})
+	}
+
+	// a language hint only applies to the code block right after it
+	hint := p.hint
+	p.hint = languageHint{}
+
 	if elem.Paragraph != nil {
+		var checked bool
+
+		code := true
+
+		for _, pelem := range elem.Paragraph.Elements {
+			if !(pelem.TextRun != nil && p.isCode(pelem.TextRun)) {
+				code = false
+				break
+			}
+		}
+
+		// list items hold a single paragraph, so a hint in one is kept for
+		// the code block in the next rather than leaving an empty item.
+		if code && elem.Paragraph.Bullet != nil {
+			if lang := parseLanguageHint(elem.Paragraph); lang != "" {
+				p.hint = languageHint{lang: lang, parent: origNode}
+				return nil
+			}
+		}
+
 		if elem.Paragraph.Bullet != nil {
 			listID := elem.Paragraph.Bullet.ListId
 			nl := elem.Paragraph.Bullet.NestingLevel
-			glyphType := p.doc.Lists[listID].ListProperties.NestingLevels[nl].GlyphType
+			levels := p.doc.Lists[listID].ListProperties.NestingLevels
 
 			var listToken Token
 			var bulletToken Token
 
-			switch glyphType {
-			case "DECIMAL":
+			switch {
+			case listType(levels[nl]) != "":
 				listToken = TokenOrderedList
 				bulletToken = TokenOrderedBullet
+			case isChecklist(levels[nl]):
+				listToken = TokenCheckList
+				bulletToken = TokenCheckBullet
+				checked = isChecked(elem.Paragraph)
 			default:
 				listToken = TokenUnorderedList
 				bulletToken = TokenUnorderedBullet
@@ -69,74 +171,121 @@ func (p *parser) parseElement(elem *docs.StructuralElement, origNode *Node) erro
 			counter := m[nl]
 
 			for i := node.BulletNesting; i <= nl; i++ {
-				node = node.append(&Node{Token: listToken, BulletNesting: i})
+				node = node.append(&Node{Token: listToken, BulletNesting: i, ListType: listType(levels[i])})
 			}
 
-			node = node.append(&Node{Token: bulletToken, ListNumber: counter, BulletNesting: nl})
+			node = node.append(&Node{Token: bulletToken, ListNumber: counter, BulletNesting: nl, Checked: checked})
 		}
 
-		code := true
+		for _, id := range elem.Paragraph.PositionedObjectIds {
+			p.parsePositionedObject(id, node)
+		}
 
-		for _, pelem := range elem.Paragraph.Elements {
-			if !(pelem.TextRun != nil && pelem.TextRun.TextStyle.WeightedFontFamily != nil && pelem.TextRun.TextStyle.WeightedFontFamily.FontFamily == "Consolas") {
-				code = false
-				break
+		if code {
+			// consecutive code paragraphs are one block
+			if n := len(node.Children); n > 0 && node.Children[n-1].Token == TokenCode {
+				node = node.Children[n-1]
+			} else {
+				node = node.append(&Node{Token: TokenCode})
+				p.applyLanguageHint(hint, origNode, node)
 			}
-		}
 
-		if code && node.Token != TokenCode {
-			node = node.append(&Node{Token: TokenCode})
-		} else if !code {
-			node = node.append(&Node{Token: TokenParagraph})
+			if node.Content == "" && node.Language == "" {
+				if lang := parseLanguageHint(elem.Paragraph); lang != "" {
+					node.Language = lang
+					return nil
+				}
+			}
+		} else {
+			style := elem.Paragraph.ParagraphStyle.NamedStyleType
 
-			var headingLevel int
-			switch elem.Paragraph.ParagraphStyle.NamedStyleType {
-			case "HEADING_1":
-				headingLevel = 1
-			case "HEADING_2":
-				headingLevel = 2
-			case "HEADING_3":
-				headingLevel = 3
-			case "HEADING_4":
-				headingLevel = 4
-			case "HEADING_5":
-				headingLevel = 5
-			case "HEADING_6":
-				headingLevel = 6
+			if p.opts.Titles == TitlesFrontMatter && p.parseFrontMatter(style, elem.Paragraph) {
+				return nil
 			}
 
-			if headingLevel > 0 {
-				node = node.append(&Node{Token: TokenHeading, Repeat: headingLevel})
+			if p.headingLevel(style) == 0 && p.isQuote(elem.Paragraph) {
+				node = quoteNode(node)
 			}
-		}
 
-		for _, pelem := range elem.Paragraph.Elements {
-			if node.Token == TokenCode && pelem.TextRun != nil {
-				node.Content += pelem.TextRun.Content
-			} else if pelem.TextRun != nil {
-				paraNode := node
-				tr := pelem.TextRun
-				ts := tr.TextStyle
-				if ts != nil {
-					if ts.Bold {
-						paraNode = paraNode.append(&Node{Token: TokenBold})
-					}
-					if ts.Italic {
-						paraNode = paraNode.append(&Node{Token: TokenItalic})
-					}
-					if ts.Link != nil {
-						paraNode = paraNode.append(&Node{Token: TokenLink, Url: ts.Link.Url})
-					}
+			if style == "SUBTITLE" && p.opts.Titles == TitlesHeading {
+				node = node.append(&Node{Token: TokenSubtitle})
+			} else {
+				node = node.append(&Node{Token: TokenParagraph, Centered: elem.Paragraph.ParagraphStyle.Alignment == "CENTER"})
+			}
+
+			// a paragraph of just a hint labels the code block after it
+			if node.parent == origNode && p.headingLevel(style) == 0 {
+				if lang := parseLanguageHint(elem.Paragraph); lang != "" {
+					p.hint = languageHint{lang: lang, parent: origNode, label: node}
 				}
+			}
 
-				paraNode.append(&Node{Token: TokenPlain, Content: tr.Content})
+			if level := p.headingLevel(elem.Paragraph.ParagraphStyle.NamedStyleType); level > 0 {
+				node = node.append(&Node{Token: TokenHeading, Repeat: level, Anchor: p.anchors[elem.Paragraph]})
+			}
+		}
+
+		var equationEnd int64
+
+		for i, pelem := range elem.Paragraph.Elements {
+			// the text of an equation is part of the equation
+			if pelem.StartIndex < equationEnd {
+				continue
+			}
 
+			if pelem.Equation != nil {
+				equationEnd = pelem.EndIndex
+				parseEquation(elem.Paragraph, i, node)
+			}
+
+			if pelem.TextRun != nil && p.dropSuggestion(pelem.TextRun, node.Token == TokenCode) {
+				continue
+			}
+
+			if node.Token == TokenCode && pelem.TextRun != nil {
+				// soft line breaks are just newlines in code
+				node.Content += strings.Replace(pelem.TextRun.Content, "\u000b", "\n", -1)
+			} else if pelem.TextRun != nil {
+				p.parseTextRun(node, pelem.TextRun, pelem.StartIndex, checked)
 			}
 
 			if pelem.InlineObjectElement != nil {
 				node.append(&Node{Token: TokenImage, ObjectId: pelem.InlineObjectElement.InlineObjectId})
 			}
+
+			if pelem.FootnoteReference != nil {
+				id := pelem.FootnoteReference.FootnoteId
+				num := p.footnoteNumber(id)
+				p.footnoteRefs[id]++
+				node.append(&Node{Token: TokenFootnoteRef, FootnoteNum: num, FootnoteRef: p.footnoteRefs[id]})
+			}
+
+			if pelem.HorizontalRule != nil {
+				node.append(&Node{Token: TokenHorizontalRule})
+			}
+
+			if pelem.PageBreak != nil && p.opts.PageBreaks != PageBreaksIgnore {
+				node.append(&Node{Token: TokenPageBreak})
+			}
+
+			if pelem.Person != nil && pelem.Person.PersonProperties != nil {
+				p.parsePerson(pelem.Person.PersonProperties, node)
+			}
+
+			if pelem.RichLink != nil && pelem.RichLink.RichLinkProperties != nil {
+				props := pelem.RichLink.RichLinkProperties
+				title := props.Title
+				if title == "" {
+					title = props.Uri
+				}
+
+				node.append(&Node{Token: TokenLink, Url: props.Uri}).append(&Node{Token: TokenPlain, Content: title})
+			}
+
+			p.warnElement(pelem)
 		}
+
+		trimLineBreaks(node)
 	}
 
 	if elem.Table != nil {
@@ -145,17 +294,267 @@ func (p *parser) parseElement(elem *docs.StructuralElement, origNode *Node) erro
 		}
 	}
 
+	if elem.TableOfContents != nil {
+		p.parseTOC(node)
+	}
+
 	return nil
 }
 
+// parsePositionedObject appends a positioned object, which floats next to
+// the paragraph anchoring it.
+func (p *parser) parsePositionedObject(id string, node *Node) {
+	obj, ok := p.doc.PositionedObjects[id]
+	if !ok || obj.PositionedObjectProperties == nil {
+		return
+	}
+
+	var float string
+
+	if pos := obj.PositionedObjectProperties.Positioning; pos != nil {
+		switch pos.Layout {
+		case "WRAP_TEXT", "BREAK_LEFT":
+			// text is to the right of the object
+			float = "left"
+		case "BREAK_RIGHT":
+			float = "right"
+		}
+	}
+
+	node.append(&Node{Token: TokenFloat, Float: float}).append(&Node{Token: TokenImage, ObjectId: id})
+}
+
+// parsePerson appends a person chip as a mailto link with the person's name.
+func (p *parser) parsePerson(props *docs.PersonProperties, node *Node) {
+	name := props.Name
+	if name == "" {
+		name = props.Email
+	}
+
+	if props.Email == "" {
+		node.append(&Node{Token: TokenPlain, Content: name})
+		return
+	}
+
+	node.append(&Node{Token: TokenLink, Url: "mailto:" + props.Email}).append(&Node{Token: TokenPlain, Content: name})
+}
+
+// warnElement reports paragraph elements that are not converted to
+// Options.Warnings.
+func (p *parser) warnElement(pelem *docs.ParagraphElement) {
+	if p.opts.Warnings == nil {
+		return
+	}
+
+	var kind string
+
+	switch {
+	case pelem.TextRun != nil, pelem.InlineObjectElement != nil, pelem.FootnoteReference != nil,
+		pelem.HorizontalRule != nil, pelem.PageBreak != nil, pelem.Person != nil, pelem.RichLink != nil,
+		pelem.Equation != nil:
+		return
+	case pelem.AutoText != nil:
+		kind = "auto text (" + pelem.AutoText.Type + ")"
+	case pelem.ColumnBreak != nil:
+		kind = "column break"
+	default:
+		kind = "unknown element"
+	}
+
+	fmt.Fprintf(p.opts.Warnings, "warning: skipping %s at index %d\n", kind, pelem.StartIndex)
+}
+
+// dropSuggestion reports whether the text run is a suggestion that is not
+// part of the export: deletions when accepting suggestions, and insertions
+// when rejecting them. Code blocks can't mark suggestions, so they are
+// accepted there.
+func (p *parser) dropSuggestion(tr *docs.TextRun, code bool) bool {
+	switch p.opts.Suggestions {
+	case SuggestionsAccept:
+		return len(tr.SuggestedDeletionIds) > 0
+	case SuggestionsReject:
+		return len(tr.SuggestedInsertionIds) > 0
+	case SuggestionsMark:
+		return code && len(tr.SuggestedDeletionIds) > 0
+	}
+
+	return false
+}
+
+// parseTextRun appends the text run to the node, wrapped in its styles. Soft
+// line breaks split the run, and are appended to the node between the parts
+// so that styles are closed before the break. The index of the run is used in
+// warnings.
+func (p *parser) parseTextRun(node *Node, tr *docs.TextRun, index int64, checked bool) {
+	text := tr.Content
+	if node.Token == TokenHeading {
+		// headings are a single line in markdown
+		text = strings.Replace(text, "\u000b", " ", -1)
+	}
+
+	var url string
+	if tr.TextStyle != nil && tr.TextStyle.Link != nil {
+		url = p.linkURL(tr.TextStyle.Link, index)
+	}
+
+	var tail string
+
+	if p.opts.Suggestions == SuggestionsMark && len(tr.SuggestedInsertionIds)+len(tr.SuggestedDeletionIds) > 0 {
+		// the end of the paragraph stays outside the mark
+		trimmed := strings.TrimRight(text, "\n")
+		text, tail = trimmed, text[len(trimmed):]
+	}
+
+	for i, content := range strings.Split(text, "\u000b") {
+		if i > 0 {
+			node.append(&Node{Token: TokenLineBreak})
+		}
+
+		if content == "" {
+			continue
+		}
+
+		paraNode := node
+		if p.opts.Suggestions == SuggestionsMark {
+			switch {
+			case len(tr.SuggestedInsertionIds) > 0:
+				paraNode = paraNode.append(&Node{Token: TokenInsertion})
+			case len(tr.SuggestedDeletionIds) > 0:
+				paraNode = paraNode.append(&Node{Token: TokenDeletion})
+			}
+		}
+
+		ts := tr.TextStyle
+		if ts != nil {
+			if ts.Bold {
+				paraNode = paraNode.append(&Node{Token: TokenBold})
+			}
+			if ts.Italic {
+				paraNode = paraNode.append(&Node{Token: TokenItalic})
+			}
+			// docs strikes through checked items; the checkbox says enough.
+			if ts.Strikethrough && !checked {
+				paraNode = paraNode.append(&Node{Token: TokenStrikethrough})
+			}
+			// links are underlined by docs; don't double up on them.
+			if ts.Underline && ts.Link == nil {
+				paraNode = paraNode.append(&Node{Token: TokenUnderline})
+			}
+			switch ts.BaselineOffset {
+			case "SUPERSCRIPT":
+				paraNode = paraNode.append(&Node{Token: TokenSuperscript})
+			case "SUBSCRIPT":
+				paraNode = paraNode.append(&Node{Token: TokenSubscript})
+			}
+			if ts.Link != nil {
+				paraNode = paraNode.append(&Node{Token: TokenLink, Url: url})
+			}
+		}
+
+		if p.isCode(tr) && strings.TrimSpace(content) != "" {
+			// keep surrounding whitespace out of the code span
+			trimmed := strings.TrimSpace(content)
+			start := strings.Index(content, trimmed)
+
+			if start > 0 {
+				paraNode.append(&Node{Token: TokenPlain, Content: content[:start]})
+			}
+
+			paraNode.append(&Node{Token: TokenCode}).append(&Node{Token: TokenPlain, Content: trimmed})
+
+			if rest := content[start+len(trimmed):]; rest != "" {
+				paraNode.append(&Node{Token: TokenPlain, Content: rest})
+			}
+		} else {
+			paraNode.append(&Node{Token: TokenPlain, Content: content})
+		}
+	}
+
+	if tail != "" {
+		node.append(&Node{Token: TokenPlain, Content: tail})
+	}
+}
+
+// trimLineBreaks removes line breaks from the end of a paragraph, where they
+// would only leave a stray break behind.
+func trimLineBreaks(node *Node) {
+	for i := len(node.Children) - 1; i >= 0; i-- {
+		child := node.Children[i]
+
+		switch {
+		case child.Token == TokenPlain && strings.TrimSpace(child.Content) == "":
+		case child.Token == TokenLineBreak:
+			node.Children = append(node.Children[:i], node.Children[i+1:]...)
+		default:
+			return
+		}
+	}
+}
+
 func (p *parser) parseTable(table *docs.Table, node *Node) error {
-	tableNode := node.append(&Node{Token: TokenTable})
+	if p.opts.Admonitions != AdmonitionsNone {
+		if kind, label, ok := admonitionKind(table); ok {
+			return p.parseAdmonition(table, kind, label, node)
+		}
+	}
+
+	tableNode := node.append(&Node{Token: TokenTable, Columns: table.Columns})
+	// cells covered by a merged cell are still reported by docs, and must be
+	// skipped. They are tracked by row and column.
+	covered := map[[2]int]bool{}
+
+	// the header is the first row, and the rows its cells span down into.
+	var head *Node
+	var headerRows int
+
+	if p.opts.TableHeader {
+		headerRows = 1
+	}
+
+	for i, row := range table.TableRows {
+		rowParent := tableNode
+		cellToken := Token(TokenTableCell)
+
+		if i < headerRows {
+			if head == nil {
+				head = tableNode.append(&Node{Token: TokenTableHead})
+			}
+
+			rowParent = head
+			cellToken = TokenTableHeaderCell
+		}
+
+		rowNode := rowParent.append(&Node{Token: TokenTableRow})
+		for j, cell := range row.TableCells {
+			if covered[[2]int{i, j}] {
+				continue
+			}
+
+			cellNode := rowNode.append(&Node{Token: cellToken, ColSpan: 1, RowSpan: 1})
+
+			if style := cell.TableCellStyle; style != nil {
+				if style.ColumnSpan > 1 {
+					cellNode.ColSpan = style.ColumnSpan
+				}
+
+				if style.RowSpan > 1 {
+					cellNode.RowSpan = style.RowSpan
+				}
+			}
+
+			if i < headerRows && i+int(cellNode.RowSpan) > headerRows {
+				headerRows = i + int(cellNode.RowSpan)
+			}
+
+			for r := 0; r < int(cellNode.RowSpan); r++ {
+				for c := 0; c < int(cellNode.ColSpan); c++ {
+					if r != 0 || c != 0 {
+						covered[[2]int{i + r, j + c}] = true
+					}
+				}
+			}
 
-	for _, row := range table.TableRows {
-		rowNode := tableNode.append(&Node{Token: TokenTableRow})
-		for _, cell := range row.TableCells {
 			for _, elem := range cell.Content {
-				cellNode := rowNode.append(&Node{Token: TokenTableCell})
 				if err := p.parseElement(elem, cellNode); err != nil {
 					return err
 				}
@@ -165,3 +564,228 @@ func (p *parser) parseTable(table *docs.Table, node *Node) error {
 
 	return nil
 }
+
+// isCode reports whether the text run is set in a code font.
+func (p *parser) isCode(tr *docs.TextRun) bool {
+	return tr.TextStyle != nil && tr.TextStyle.WeightedFontFamily != nil && p.codeFonts[strings.ToLower(tr.TextStyle.WeightedFontFamily.FontFamily)]
+}
+
+var languageHintRegexp = regexp.MustCompile(`^lang:\s*([\w+#.-]+)\s*$`)
+
+// languageHint is a language named before the code block it is for, by a
+// hint in a list item or a label paragraph, which is removed once the code
+// block is found.
+type languageHint struct {
+	lang   string
+	parent *Node
+	label  *Node
+}
+
+// applyLanguageHint names the language of a new code block from the hint
+// given right before it, under the same parent.
+func (p *parser) applyLanguageHint(hint languageHint, parent, code *Node) {
+	if hint.lang == "" || hint.parent != parent {
+		return
+	}
+
+	if hint.label != nil {
+		n := len(parent.Children)
+		if n < 2 || parent.Children[n-2] != hint.label {
+			return
+		}
+
+		parent.Children = append(parent.Children[:n-2], parent.Children[n-1])
+	}
+
+	code.Language = hint.lang
+}
+
+// parseLanguageHint returns the language of a code block if the paragraph is
+// a `lang: go` style hint.
+func parseLanguageHint(para *docs.Paragraph) string {
+	var text string
+	for _, pelem := range para.Elements {
+		if pelem.TextRun != nil {
+			text += pelem.TextRun.Content
+		}
+	}
+
+	if m := languageHintRegexp.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// listType returns the html list type for ordered lists, or an empty string
+// if the list is not ordered. Zero-padded decimal lists are returned as "01".
+func listType(level *docs.NestingLevel) string {
+	switch level.GlyphType {
+	case "DECIMAL":
+		return "1"
+	case "ZERO_DECIMAL":
+		return "01"
+	case "ALPHA":
+		return "a"
+	case "UPPER_ALPHA":
+		return "A"
+	case "ROMAN":
+		return "i"
+	case "UPPER_ROMAN":
+		return "I"
+	}
+
+	return ""
+}
+
+// isChecklist reports whether the list level is a checklist. The API does
+// not call these out, but they are the only lists with neither a glyph type
+// nor a glyph symbol.
+func isChecklist(level *docs.NestingLevel) bool {
+	return level.GlyphSymbol == "" && (level.GlyphType == "" || level.GlyphType == "GLYPH_TYPE_UNSPECIFIED")
+}
+
+// isChecked reports whether a checklist item is checked. The checked state is
+// not exported either, but docs strikes through the text of checked items.
+func isChecked(para *docs.Paragraph) bool {
+	var found bool
+
+	for _, pelem := range para.Elements {
+		if pelem.TextRun == nil || strings.TrimSpace(pelem.TextRun.Content) == "" {
+			continue
+		}
+
+		if pelem.TextRun.TextStyle == nil || !pelem.TextRun.TextStyle.Strikethrough {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
+
+// headingLevel returns the level of the heading in the output, or 0 if the
+// style is not a heading. The title is a heading if Options.Titles says so.
+func (p *parser) headingLevel(namedStyleType string) int {
+	level := styleLevel(namedStyleType)
+
+	switch {
+	case namedStyleType == "TITLE" && p.opts.Titles == TitlesHeading:
+		level = 1
+	case level > 0:
+		level += p.titleShift
+	}
+
+	if level == 0 {
+		return 0
+	}
+
+	level += p.opts.HeadingOffset
+
+	switch {
+	case level > 6:
+		return 6
+	case level < 1:
+		return 1
+	}
+
+	return level
+}
+
+// hasTitle reports whether any paragraph of the content is in the title
+// style.
+func hasTitle(content []*docs.StructuralElement) bool {
+	for _, elem := range content {
+		if elem.Paragraph != nil && elem.Paragraph.ParagraphStyle != nil &&
+			elem.Paragraph.ParagraphStyle.NamedStyleType == "TITLE" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// styleLevel returns the level of the heading style, or 0 if the style is
+// not a heading.
+func styleLevel(namedStyleType string) int {
+	switch namedStyleType {
+	case "HEADING_1":
+		return 1
+	case "HEADING_2":
+		return 2
+	case "HEADING_3":
+		return 3
+	case "HEADING_4":
+		return 4
+	case "HEADING_5":
+		return 5
+	case "HEADING_6":
+		return 6
+	}
+
+	return 0
+}
+
+// footnoteNumber returns the number of the footnote in order of reference,
+// allocating one if this is the first time the footnote has been seen.
+func (p *parser) footnoteNumber(id string) int {
+	if num, ok := p.footnoteMap[id]; ok {
+		return num
+	}
+
+	p.footnotes = append(p.footnotes, id)
+	p.footnoteMap[id] = len(p.footnotes)
+
+	return len(p.footnotes)
+}
+
+// parseFootnotes adds the definitions of all referenced footnotes to the
+// end of the document, before any footers. Footnotes first referenced in
+// another footnote are added after it.
+func (p *parser) parseFootnotes(node *Node) error {
+	if len(p.footnotes) == 0 {
+		return nil
+	}
+
+	// formats that give footnotes a section of their own put it at the level
+	// of the document's top headings.
+	footnotesNode := &Node{Token: TokenFootnotes, Repeat: p.headingLevel("HEADING_1")}
+
+	// footnotes referenced while parsing one are queued after it
+	for i := 0; i < len(p.footnotes); i++ {
+		id := p.footnotes[i]
+
+		footnote, ok := p.doc.Footnotes[id]
+		if !ok {
+			continue
+		}
+
+		footnoteNode := footnotesNode.append(&Node{Token: TokenFootnote, FootnoteNum: p.footnoteMap[id]})
+		for _, elem := range footnote.Content {
+			if err := p.parseElement(elem, footnoteNode); err != nil {
+				return err
+			}
+		}
+
+		// docs prefixes the footnote text with a space to separate it from the number
+		if plain := firstPlain(footnoteNode); plain != nil {
+			plain.Content = strings.TrimLeft(plain.Content, " ")
+		}
+	}
+
+	// references are only all counted once every footnote is parsed
+	for _, footnoteNode := range footnotesNode.Children {
+		footnoteNode.FootnoteRef = p.footnoteRefs[p.footnotes[footnoteNode.FootnoteNum-1]]
+	}
+
+	i := len(node.Children)
+	if i > 0 && node.Children[i-1].Token == TokenFooter {
+		i--
+	}
+
+	footnotesNode.parent = node
+	node.Children = append(node.Children[:i], append([]*Node{footnotesNode}, node.Children[i:]...)...)
+
+	return nil
+}
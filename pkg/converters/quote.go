@@ -0,0 +1,192 @@
+package converters
+
+import (
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// admonitionLabels are the emoji that mark a single cell table as a callout,
+// and the kind of admonition they make. Variation selectors are skipped.
+var admonitionLabels = []struct {
+	emoji string
+	kind  string
+}{
+	{"ℹ", "note"},
+	{"📝", "note"},
+	{"💡", "tip"},
+	{"❗", "important"},
+	{"❕", "important"},
+	{"⚠", "warning"},
+	{"🛑", "caution"},
+	{"⛔", "caution"},
+	{"🚫", "caution"},
+}
+
+// admonitionNames are the names MkDocs and Docusaurus give the admonition
+// kinds that github names differently.
+var admonitionNames = map[AdmonitionStyle]map[string]string{
+	AdmonitionsMkDocs: {
+		"important": "info",
+		"caution":   "danger",
+	},
+	AdmonitionsDocusaurus: {
+		"important": "info",
+	},
+}
+
+// admonitionTitles are the titles formats without admonitions of their own
+// give each kind.
+var admonitionTitles = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+}
+
+// isQuote reports whether the paragraph is indented enough to be a block
+// quote.
+func (p *parser) isQuote(para *docs.Paragraph) bool {
+	if !p.opts.Blockquotes || para.Bullet != nil || para.ParagraphStyle == nil {
+		return false
+	}
+
+	indent := para.ParagraphStyle.IndentStart
+	if indent == nil || indent.Unit != "PT" {
+		return false
+	}
+
+	min := p.opts.QuoteIndent
+	if min == 0 {
+		min = DefaultQuoteIndent
+	}
+
+	return indent.Magnitude >= min
+}
+
+// quoteNode returns the block quote to append an indented paragraph to.
+// Consecutive indented paragraphs are one quote.
+func quoteNode(node *Node) *Node {
+	if n := len(node.Children); n > 0 && node.Children[n-1].Token == TokenBlockquote {
+		return node.Children[n-1]
+	}
+
+	return node.append(&Node{Token: TokenBlockquote})
+}
+
+// admonitionKind returns the kind of admonition a callout table makes, and
+// the emoji label to strip from its text. Callouts are tables of one cell
+// that is shaded, or starts with one of the admonitionLabels.
+func admonitionKind(table *docs.Table) (string, string, bool) {
+	if len(table.TableRows) != 1 || len(table.TableRows[0].TableCells) != 1 {
+		return "", "", false
+	}
+
+	cell := table.TableRows[0].TableCells[0]
+
+	text := strings.TrimLeft(firstText(cell.Content), " ")
+	for _, label := range admonitionLabels {
+		if strings.HasPrefix(text, label.emoji) {
+			emoji := label.emoji
+			if strings.HasPrefix(text[len(emoji):], "\ufe0f") {
+				emoji += "\ufe0f"
+			}
+
+			return label.kind, emoji, true
+		}
+	}
+
+	if isShaded(cell.TableCellStyle) {
+		return "note", "", true
+	}
+
+	return "", "", false
+}
+
+// firstText returns the text of the first paragraph of the content.
+func firstText(content []*docs.StructuralElement) string {
+	for _, elem := range content {
+		if elem.Paragraph == nil {
+			continue
+		}
+
+		var text string
+		for _, pelem := range elem.Paragraph.Elements {
+			if pelem.TextRun != nil {
+				text += pelem.TextRun.Content
+			}
+		}
+
+		return text
+	}
+
+	return ""
+}
+
+// isShaded reports whether the cell has a background colour other than white.
+func isShaded(style *docs.TableCellStyle) bool {
+	if style == nil || style.BackgroundColor == nil || style.BackgroundColor.Color == nil {
+		return false
+	}
+
+	rgb := style.BackgroundColor.Color.RgbColor
+	return rgb != nil && !(rgb.Red == 1 && rgb.Green == 1 && rgb.Blue == 1)
+}
+
+// parseAdmonition appends the single cell of a callout table as an
+// admonition, without its emoji label.
+func (p *parser) parseAdmonition(table *docs.Table, kind, label string, node *Node) error {
+	noteNode := node.append(&Node{Token: TokenAdmonition, Admonition: kind, AdmonitionStyle: p.opts.Admonitions})
+
+	for _, elem := range table.TableRows[0].TableCells[0].Content {
+		if err := p.parseElement(elem, noteNode); err != nil {
+			return err
+		}
+	}
+
+	if plain := firstPlain(noteNode); plain != nil && label != "" {
+		content := strings.TrimLeft(plain.Content, " ")
+		if strings.HasPrefix(content, label) {
+			plain.Content = strings.TrimLeft(content[len(label):], " ")
+		}
+	}
+
+	return nil
+}
+
+// prefixLines prefixes each line of s, leaving empty lines empty apart from
+// the trimmed prefix.
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// markdownAdmonition writes an admonition in the markdown style it was parsed
+// for.
+func markdownAdmonition(n *Node, s string) string {
+	kind := n.Admonition
+
+	name := kind
+	if alias, ok := admonitionNames[n.AdmonitionStyle][kind]; ok {
+		name = alias
+	}
+
+	switch n.AdmonitionStyle {
+	case AdmonitionsMkDocs:
+		return "\n!!! " + name + "\n\n" + prefixLines(s, "    ") + "\n"
+	case AdmonitionsDocusaurus:
+		return "\n:::" + name + "\n\n" + s + "\n\n:::\n"
+	default:
+		return "\n> [!" + strings.ToUpper(kind) + "]\n" + prefixLines(s, "> ") + "\n"
+	}
+}
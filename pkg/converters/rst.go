@@ -0,0 +1,487 @@
+package converters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+// rstUnderlines are the characters that underline each heading level.
+const rstUnderlines = `=-~^"'`
+
+// rstIndent is the indent of list bodies and directive content. List
+// markers are padded to the same width, so nested lists line up at every
+// level.
+const rstIndent = "   "
+
+var restructuredText = TagSet{
+	TokenPlain: Tag{
+		Collapse: true,
+		LeftPad:  true,
+		Escape:   rstEscape,
+	},
+	TokenBold: Tag{
+		Collapse:        true,
+		LeftPad:         true,
+		TrimInside:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return rstInline("**", s) },
+	},
+	TokenItalic: Tag{
+		TrimInside:      true,
+		LeftPad:         true,
+		Collapse:        true,
+		RequiresContent: true,
+		Before:          func(s string) string { return rstInline("*", s) },
+	},
+	// docutils has no strikethrough or underline
+	TokenStrikethrough: Tag{
+		LeftPad:  true,
+		Collapse: true,
+	},
+	TokenUnderline: Tag{
+		LeftPad:  true,
+		Collapse: true,
+	},
+	TokenSuperscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		// roles must be set apart from the word before them
+		Before: func(s string) string { return `\ :sup:` + "`" + s },
+		After:  func(s string) string { return s + "`\\ " },
+	},
+	TokenSubscript: Tag{
+		Collapse:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		Before:          func(s string) string { return `\ :sub:` + "`" + s },
+		After:           func(s string) string { return s + "`\\ " },
+	},
+	TokenParagraph: Tag{
+		TrimInside: true,
+		Before: func(s string) string {
+			// paragraphs with line breaks are line blocks
+			if strings.Contains(s, "\n| ") {
+				s = "| " + s
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenUnorderedList: Tag{},
+	TokenUnorderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return rstListItem(times, "-  ", s) },
+		Before:          func(s string) string { return "\n" + s },
+		After:           func(s string) string { return s + "\n" },
+	},
+	TokenOrderedList: Tag{},
+	TokenOrderedBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return rstListItem(times, "#. ", s) },
+		NodeBefore: func(n *Node, s string) string {
+			// auto-numbered lists count from 1, so lettered and roman lists
+			// set their sequence with their first item.
+			if start, ok := rstEnumerators[n.parent.ListType]; ok && listPosition(n) == 1 {
+				s = strings.Replace(s, "#.", start, 1)
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenCheckList: Tag{},
+	TokenCheckBullet: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat:          func(times int, s string) string { return rstListItem(times, "-  ", s) },
+		NodeBefore: func(n *Node, s string) string {
+			// the checkbox goes after the marker made by Repeat
+			marker := strings.Index(s, "-  ") + len("-  ")
+			if n.Checked {
+				return "\n" + s[:marker] + "[x] " + s[marker:]
+			}
+			return "\n" + s[:marker] + "[ ] " + s[marker:]
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenHeading: Tag{
+		TrimInside:      true,
+		RequiresContent: true,
+		Repeat: func(times int, s string) string {
+			if times > len(rstUnderlines) {
+				times = len(rstUnderlines)
+			}
+			return s + "\n" + strings.Repeat(rstUnderlines[times-1:times], displayWidth(s))
+		},
+		After:  func(s string) string { return s + "\n" },
+		Anchor: func(anchor, s string) string { return ".. _" + anchor + ":\n\n" + s },
+	},
+	TokenTable: Tag{
+		GridTable: rstGridTable,
+	},
+	// tables are laid out by GridTable
+	TokenTableHead:       Tag{},
+	TokenTableHeaderCell: Tag{},
+	TokenTableCell:       Tag{},
+	TokenTableRow:        Tag{},
+	TokenImage: Tag{
+		MapFile: rstImage,
+	},
+	TokenCode: Tag{
+		Collapse:        true,
+		NoEscape:        true,
+		RequiresContent: true,
+		NoPadAfter:      true,
+		NodeBefore: func(n *Node, s string) string {
+			if strings.Contains(s, "\n") {
+				directive := "\n.. code-block::"
+				if n.Language != "" {
+					directive += " " + n.Language
+				}
+				return directive + "\n\n" + prefixLines(strings.TrimRight(s, "\n"), rstIndent) + "\n"
+			}
+			// inline literals can't hold backticks next to their marks, and
+			// have no escapes, so those go in the code role instead.
+			if strings.Contains(s, "`") {
+				return "\\ :code:`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(s) + "`\\ "
+			}
+			return "``" + s + "``"
+		},
+	},
+	TokenLink: Tag{
+		LeftPad: true,
+		Link: func(href, s string) string {
+			// anonymous references, as the same text may link to different places
+			if strings.HasPrefix(href, "#") {
+				return fmt.Sprintf("`%s <%s_>`__", s, href[1:])
+			}
+			return fmt.Sprintf("`%s <%s>`__", s, href)
+		},
+	},
+	TokenTOC: Tag{
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenTOCList: Tag{
+		Before: func(s string) string { return "\n" + s },
+	},
+	TokenTOCEntry: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n-  " + indentLines(s, rstIndent) },
+		After:      func(s string) string { return s + "\n" },
+	},
+	TokenLineBreak: Tag{
+		NoPadAfter: true,
+		Before:     func(s string) string { return "\n| " },
+	},
+	TokenSubtitle: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n.. class:: subtitle\n\n" + s },
+		After:      func(s string) string { return s + "\n" },
+	},
+	// front matter becomes the bibliographic fields of the document
+	TokenFrontMatter: Tag{
+		NodeBefore: rstFields,
+	},
+	TokenBlockquote: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n" + prefixLines(s, rstIndent) + "\n" },
+	},
+	TokenAdmonition: Tag{
+		TrimInside: true,
+		NodeBefore: func(n *Node, s string) string {
+			return "\n.. " + n.Admonition + "::\n\n" + prefixLines(s, rstIndent) + "\n"
+		},
+	},
+	TokenHeader: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return ".. header::\n\n" + prefixLines(s, rstIndent) + "\n" },
+	},
+	TokenFooter: Tag{
+		TrimInside: true,
+		Before:     func(s string) string { return "\n.. footer::\n\n" + prefixLines(s, rstIndent) + "\n" },
+	},
+	// CriticMarkup, as rst has no markup for these
+	TokenInsertion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "{++" + s },
+		After:           func(s string) string { return s + "++}" },
+	},
+	TokenDeletion: Tag{
+		NoPadAfter:      true,
+		RequiresContent: true,
+		Before:          func(s string) string { return "{--" + s },
+		After:           func(s string) string { return s + "--}" },
+	},
+	TokenMath: Tag{
+		NoEscape:   true,
+		NoPadAfter: true,
+		Before:     func(s string) string { return ":math:`" + s },
+		After:      func(s string) string { return s + "`" },
+	},
+	TokenMathBlock: Tag{
+		NoEscape: true,
+		Before:   func(s string) string { return ".. math::\n\n" + prefixLines(s, rstIndent) },
+	},
+	TokenFloat: Tag{
+		RequiresContent: true,
+		NodeBefore: func(n *Node, s string) string {
+			s = strings.TrimSpace(s)
+			if n.Float != "" {
+				s += "\n" + rstIndent + ":align: " + n.Float
+			}
+			return "\n" + s
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenFigure: Tag{
+		Before: func(s string) string {
+			return "\n" + strings.Replace(strings.TrimLeft(s, "\n"), ".. image::", ".. figure::", 1)
+		},
+		After: func(s string) string { return s + "\n" },
+	},
+	TokenFigureCaption: Tag{
+		TrimInside: true,
+		// the image before the caption ends with a blank line
+		Before: func(s string) string { return prefixLines(s, rstIndent) },
+	},
+	TokenHorizontalRule: Tag{
+		Before: func(s string) string { return "\n----------\n" },
+	},
+	TokenPageBreak: Tag{
+		Before: func(s string) string { return "\n.. raw:: html\n\n" + rstIndent + pageBreakDiv + "\n" },
+	},
+	TokenFootnoteRef: Tag{
+		NoPadAfter: true,
+		// the escaped space sets the reference apart without showing
+		Footnote: func(i int, s string) string { return fmt.Sprintf(`\ [%d]_`, i) },
+	},
+	TokenFootnotes: Tag{
+		Before: func(s string) string { return "\n" + s },
+	},
+	TokenFootnote: Tag{
+		TrimInside: true,
+		Footnote: func(i int, s string) string {
+			return fmt.Sprintf("\n.. [%d] %s\n", i, indentLines(s, rstIndent))
+		},
+	},
+}
+
+// rstEscape escapes the characters rst reads as inline markup.
+func rstEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '*', '`', '_', '|':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// rstInline marks up s, unless it starts or ends with other markup, which
+// rst can't nest. Bold or italic code keeps only its code markup.
+func rstInline(mark, s string) string {
+	for _, nested := range []string{"*", "`", `\ :`} {
+		if strings.HasPrefix(s, nested) || strings.HasSuffix(s, nested) || strings.HasSuffix(s, nested+`\ `) {
+			return s
+		}
+	}
+
+	return mark + s + mark
+}
+
+// rstEnumerators are the first markers of ordered lists by their list type.
+var rstEnumerators = map[string]string{
+	"a": "a.",
+	"A": "A.",
+	"i": "i.",
+	"I": "I.",
+}
+
+// rstListItem indents a list item to its nesting level, with its body lined
+// up after the marker.
+func rstListItem(nesting int, marker, s string) string {
+	indent := strings.Repeat(rstIndent, nesting)
+	return indent + marker + indentLines(s, indent+rstIndent)
+}
+
+// rstImage returns the image directive for an image, with its size and alt
+// text. Directives can't be inline, so it is set apart from any text around
+// it.
+func rstImage(file downloader.ManifestFile) string {
+	res := fmt.Sprintf("\n\n.. image:: %s\n%s:width: %dpx\n%s:height: %dpx", file.Filename, rstIndent, file.Width, rstIndent, file.Height)
+
+	if file.Description != "" {
+		res += "\n" + rstIndent + ":alt: " + strings.Replace(file.Description, "\n", " ", -1)
+	}
+
+	return res + "\n\n"
+}
+
+// rstFields turns the front matter into a field list, which docutils reads
+// as the document's bibliographic fields.
+func rstFields(n *Node, s string) string {
+	var res string
+
+	if n.Title != "" {
+		res += ":title: " + rstEscape(n.Title) + "\n"
+	}
+
+	if n.Subtitle != "" {
+		res += ":subtitle: " + rstEscape(n.Subtitle) + "\n"
+	}
+
+	return res
+}
+
+// rstGridTable lays out a grid table, which can hold cells of more than one
+// line and merged cells. Header rows are set apart with =.
+func rstGridTable(rows [][]TableCell, headerRows int) string {
+	cells, ncols := placeCells(rows)
+	if ncols == 0 {
+		return ""
+	}
+
+	widths := make([]int, ncols)
+	heights := make([]int, len(rows))
+
+	for i := range widths {
+		widths[i] = 1
+	}
+
+	for i := range heights {
+		heights[i] = 1
+	}
+
+	lineWidth := func(gc *gridCell) int {
+		var width int
+		for _, line := range gc.lines() {
+			if l := displayWidth(line); l > width {
+				width = l
+			}
+		}
+
+		return width
+	}
+
+	for _, gc := range cells {
+		if gc.cs == 1 && lineWidth(gc) > widths[gc.col] {
+			widths[gc.col] = lineWidth(gc)
+		}
+
+		if gc.rs == 1 && len(gc.lines()) > heights[gc.row] {
+			heights[gc.row] = len(gc.lines())
+		}
+	}
+
+	// merged cells take the borders between their columns and rows; the
+	// last column or row they cover grows if that is not enough.
+	for _, gc := range cells {
+		width := 3 * (gc.cs - 1)
+		for c := gc.col; c < gc.col+gc.cs; c++ {
+			width += widths[c]
+		}
+
+		if l := lineWidth(gc); l > width {
+			widths[gc.col+gc.cs-1] += l - width
+		}
+
+		height := gc.rs - 1
+		for r := gc.row; r < gc.row+gc.rs; r++ {
+			height += heights[r]
+		}
+
+		if len(gc.lines()) > height {
+			heights[gc.row+gc.rs-1] += len(gc.lines()) - height
+		}
+	}
+
+	// the header separator must run the width of the table
+	for _, gc := range cells {
+		if gc.row < headerRows && gc.row+gc.rs > headerRows {
+			headerRows = 0
+		}
+	}
+
+	xs := make([]int, ncols+1)
+	for c, width := range widths {
+		xs[c+1] = xs[c] + width + 3
+	}
+
+	ys := make([]int, len(rows)+1)
+	for r, height := range heights {
+		ys[r+1] = ys[r] + height + 1
+	}
+
+	// each column of the grid holds what is drawn in it; wide characters
+	// leave the column after them empty, and combining marks join the
+	// character before them.
+	grid := make([][]string, ys[len(rows)]+1)
+	for y := range grid {
+		grid[y] = strings.Split(strings.Repeat(" ", xs[ncols]+1), "")
+	}
+
+	set := func(x, y int, s string) {
+		// corners win over the lines running into them
+		if grid[y][x] != "+" {
+			grid[y][x] = s
+		}
+	}
+
+	for _, gc := range cells {
+		x0, x1 := xs[gc.col], xs[gc.col+gc.cs]
+		y0, y1 := ys[gc.row], ys[gc.row+gc.rs]
+
+		for _, y := range []int{y0, y1} {
+			line := "-"
+			if headerRows > 0 && headerRows < len(rows) && y == ys[headerRows] {
+				line = "="
+			}
+
+			for x := x0 + 1; x < x1; x++ {
+				set(x, y, line)
+			}
+		}
+
+		for _, x := range []int{x0, x1} {
+			for y := y0 + 1; y < y1; y++ {
+				set(x, y, "|")
+			}
+		}
+
+		for _, corner := range [][2]int{{x0, y0}, {x1, y0}, {x0, y1}, {x1, y1}} {
+			grid[corner[1]][corner[0]] = "+"
+		}
+
+		for i, line := range gc.lines() {
+			x := x0 + 2
+			for _, r := range line {
+				switch runeWidth(r) {
+				case 0:
+					grid[y0+1+i][x-1] += string(r)
+				case 2:
+					grid[y0+1+i][x], grid[y0+1+i][x+1] = string(r), ""
+					x += 2
+				default:
+					grid[y0+1+i][x] = string(r)
+					x++
+				}
+			}
+		}
+	}
+
+	lines := make([]string, len(grid))
+	for y, line := range grid {
+		lines[y] = strings.Join(line, "")
+	}
+
+	return "\n" + strings.Join(lines, "\n") + "\n"
+}
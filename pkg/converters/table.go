@@ -0,0 +1,210 @@
+package converters
+
+import (
+	"strings"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+)
+
+// tableRows returns the rows of a table, including the rows of its header.
+func tableRows(node *Node) []*Node {
+	rows := []*Node{}
+
+	for _, child := range node.Children {
+		if child.Token == TokenTableHead {
+			rows = append(rows, child.Children...)
+		} else {
+			rows = append(rows, child)
+		}
+	}
+
+	return rows
+}
+
+// TableCell is a generated table cell, for formats that lay out tables with
+// merged cells as a whole.
+type TableCell struct {
+	Content string
+	ColSpan int64
+	RowSpan int64
+}
+
+// generateTable generates the cells of a table and hands them to the tag's
+// GridTable or Table function, for formats where tables are laid out as a
+// whole instead of tag by tag. If the Table function returns false, or the
+// table has merged cells and there is no GridTable function, the table is
+// generated with the tag's other functions instead.
+func generateTable(typ string, tag Tag, node *Node, manifest downloader.Manifest, opts Options) (string, bool, error) {
+	converter := ConvertMap[typ]
+	rows := [][]TableCell{}
+
+	var headerRows int
+
+	for _, child := range node.Children {
+		if child.Token == TokenTableHead {
+			headerRows += len(child.Children)
+		}
+	}
+
+	for _, row := range tableRows(node) {
+		cells := []TableCell{}
+
+		for _, cell := range row.Children {
+			if (cell.ColSpan > 1 || cell.RowSpan > 1) && tag.GridTable == nil {
+				return "", false, nil
+			}
+
+			res, err := generateContent(typ, converter, cell, manifest, opts)
+			if err != nil {
+				return "", false, err
+			}
+
+			cells = append(cells, TableCell{Content: strings.TrimSpace(res), ColSpan: cell.ColSpan, RowSpan: cell.RowSpan})
+		}
+
+		rows = append(rows, cells)
+	}
+
+	if tag.GridTable != nil {
+		return tag.GridTable(rows, headerRows), true, nil
+	}
+
+	contents := [][]string{}
+
+	for _, row := range rows {
+		cells := []string{}
+		for _, cell := range row {
+			cells = append(cells, cell.Content)
+		}
+
+		contents = append(contents, cells)
+	}
+
+	res, ok := tag.Table(contents)
+	return res, ok, nil
+}
+
+// gridCell is a table cell placed in the grid of the table's rows and
+// columns.
+type gridCell struct {
+	content  string
+	row, col int
+	rs, cs   int
+}
+
+func (gc *gridCell) lines() []string {
+	return strings.Split(gc.content, "\n")
+}
+
+// placeCells places the cells of a table in its grid, and returns them with
+// the number of columns. Slots that rows leave out get empty cells, so every
+// slot is covered by exactly one cell.
+func placeCells(rows [][]TableCell) ([]*gridCell, int) {
+	cells := []*gridCell{}
+	occupied := map[[2]int]bool{}
+	ncols := 0
+
+	for r, row := range rows {
+		c := 0
+
+		for _, cell := range row {
+			for occupied[[2]int{r, c}] {
+				c++
+			}
+
+			gc := &gridCell{content: cell.Content, row: r, col: c, rs: 1, cs: 1}
+			if cell.RowSpan > 1 {
+				gc.rs = int(cell.RowSpan)
+			}
+			if cell.ColSpan > 1 {
+				gc.cs = int(cell.ColSpan)
+			}
+			if gc.row+gc.rs > len(rows) {
+				gc.rs = len(rows) - gc.row
+			}
+
+			for i := 0; i < gc.rs; i++ {
+				for j := 0; j < gc.cs; j++ {
+					occupied[[2]int{r + i, c + j}] = true
+				}
+			}
+
+			c += gc.cs
+			if c > ncols {
+				ncols = c
+			}
+
+			cells = append(cells, gc)
+		}
+	}
+
+	for r := range rows {
+		for c := 0; c < ncols; c++ {
+			if !occupied[[2]int{r, c}] {
+				cells = append(cells, &gridCell{row: r, col: c, rs: 1, cs: 1})
+			}
+		}
+	}
+
+	return cells, ncols
+}
+
+// markdownTable lays out a github-flavored markdown pipe table, with the
+// first row as the header. Tables with cells spanning multiple lines or rows
+// of different lengths cannot be represented and return false.
+func markdownTable(rows [][]string) (string, bool) {
+	if len(rows) == 0 {
+		return "", false
+	}
+
+	widths := make([]int, len(rows[0]))
+
+	for _, row := range rows {
+		if len(row) != len(widths) {
+			return "", false
+		}
+
+		for i, cell := range row {
+			// hard line breaks can't span lines in a cell
+			cell = strings.Replace(cell, "\\\n", "<br />", -1)
+			if strings.Contains(cell, "\n") {
+				return "", false
+			}
+
+			row[i] = strings.Replace(cell, "|", `\|`, -1)
+			if l := displayWidth(row[i]); l > widths[i] {
+				widths[i] = l
+			}
+		}
+	}
+
+	for i := range widths {
+		if widths[i] < 3 {
+			widths[i] = 3
+		}
+	}
+
+	var b strings.Builder
+
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i, cell := range row {
+			b.WriteString(" " + cell + strings.Repeat(" ", widths[i]-displayWidth(cell)) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+
+	sep := make([]string, len(widths))
+	for i, width := range widths {
+		sep[i] = strings.Repeat("-", width)
+	}
+	writeRow(sep)
+
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return "\n" + b.String(), true
+}
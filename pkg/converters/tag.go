@@ -8,15 +8,23 @@ type Tag struct {
 	Collapse        bool
 	RequiresContent bool
 	LeftPad         bool
+	NoPadAfter      bool
 	TrimInside      bool
 	ListItem        bool
+	OptionalAnchor  bool
+	Merge           bool
 	Escape          func(string) string
 	Link            func(string, string) string
 	Repeat          func(int, string) string
-	ListBefore      func(string, int) string
+	NodeBefore      func(*Node, string) string
 	Before          func(string) string
 	After           func(string) string
 	MapFile         func(downloader.ManifestFile) string
+	Footnote        func(int, string) string
+	Anchor          func(string, string) string
+	Table           func([][]string) (string, bool)
+	GridTable       func([][]TableCell, int) string
+	Fallback        string
 }
 
 type Token int
@@ -38,4 +46,34 @@ const (
 	TokenOrderedBullet   = iota
 	TokenOrderedList     = iota
 	TokenLink            = iota
+	TokenFootnoteRef     = iota
+	TokenFootnotes       = iota
+	TokenFootnote        = iota
+	TokenStrikethrough   = iota
+	TokenUnderline       = iota
+	TokenSuperscript     = iota
+	TokenSubscript       = iota
+	TokenTOC             = iota
+	TokenTOCList         = iota
+	TokenTOCEntry        = iota
+	TokenCheckList       = iota
+	TokenCheckBullet     = iota
+	TokenTableHead       = iota
+	TokenTableHeaderCell = iota
+	TokenLineBreak       = iota
+	TokenHorizontalRule  = iota
+	TokenPageBreak       = iota
+	TokenFigure          = iota
+	TokenFigureCaption   = iota
+	TokenFloat           = iota
+	TokenMath            = iota
+	TokenMathBlock       = iota
+	TokenInsertion       = iota
+	TokenDeletion        = iota
+	TokenHeader          = iota
+	TokenFooter          = iota
+	TokenSubtitle        = iota
+	TokenFrontMatter     = iota
+	TokenBlockquote      = iota
+	TokenAdmonition      = iota
 )
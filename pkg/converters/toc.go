@@ -0,0 +1,47 @@
+package converters
+
+type heading struct {
+	level  int
+	text   string
+	anchor string
+}
+
+type tocEntry struct {
+	level int
+	node  *Node
+}
+
+// parseTOC builds a nested list of links to every heading in the document.
+// The contents of the table of contents docs provides are not used, as they
+// are just a rendering of the same headings.
+func (p *parser) parseTOC(node *Node) {
+	if len(p.headings) == 0 {
+		return
+	}
+
+	tocNode := node.append(&Node{Token: TokenTOC})
+	list := tocNode.append(&Node{Token: TokenTOCList})
+
+	var stack []tocEntry
+
+	for _, h := range p.headings {
+		for len(stack) > 0 && stack[len(stack)-1].level >= h.level {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := list
+		if len(stack) > 0 {
+			entryNode := stack[len(stack)-1].node
+			last := entryNode.Children[len(entryNode.Children)-1]
+			if last.Token != TokenTOCList {
+				last = entryNode.append(&Node{Token: TokenTOCList})
+			}
+
+			parent = last
+		}
+
+		entryNode := parent.append(&Node{Token: TokenTOCEntry})
+		entryNode.append(&Node{Token: TokenLink, Url: "#" + h.anchor}).append(&Node{Token: TokenPlain, Content: h.text})
+		stack = append(stack, tocEntry{level: h.level, node: entryNode})
+	}
+}
@@ -1,7 +1,13 @@
 package converters
 
 import (
+	"fmt"
+	"html"
 	"strings"
+	"unicode"
+
+	"github.com/erikh/gdocs-export/pkg/downloader"
+	"golang.org/x/text/width"
 )
 
 // taken from golang.org/x/tools/cmd/present2md and hacked up
@@ -23,3 +29,96 @@ func markdownEscape(s string) string {
 
 	return b.String()
 }
+
+// htmlEscape escapes text for html. Spaces at either end become non-breaking,
+// so they are kept next to the tags around the text.
+func htmlEscape(s string) string {
+	if len(s) > 0 {
+		s = html.EscapeString(s)
+		if s[0] == ' ' {
+			s = "&nbsp;" + s[1:]
+		}
+		if s[len(s)-1] == ' ' {
+			s = s[:len(s)-1] + "&nbsp;"
+		}
+	}
+
+	return s
+}
+
+// runeWidth returns the number of columns r takes up in a monospace font.
+// East Asian wide characters and most emoji take two, and combining marks
+// take none.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case width.LookupRune(r).Kind() == width.EastAsianWide, width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// displayWidth returns the number of columns s takes up in a monospace font.
+func displayWidth(s string) int {
+	var w int
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+
+	return w
+}
+
+// indentLines indents every non-empty line after the first with prefix.
+func indentLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// htmlFootnoteRef returns the id of a reference to a footnote. Footnotes
+// referenced more than once number the references after the first.
+func htmlFootnoteRef(num, ref int) string {
+	if ref > 1 {
+		return fmt.Sprintf("fnref-%d-%d", num, ref)
+	}
+
+	return fmt.Sprintf("fnref-%d", num)
+}
+
+// spanAttrs returns the html attributes for a merged table cell.
+func spanAttrs(colspan, rowspan int64) string {
+	var res string
+
+	if colspan > 1 {
+		res += fmt.Sprintf(` colspan="%d"`, colspan)
+	}
+
+	if rowspan > 1 {
+		res += fmt.Sprintf(` rowspan="%d"`, rowspan)
+	}
+
+	return res
+}
+
+// imageTag returns the img tag for an image, with its alt text and title if
+// it has them.
+func imageTag(file downloader.ManifestFile) string {
+	var attrs string
+
+	if file.Description != "" {
+		attrs += fmt.Sprintf(` alt="%s"`, html.EscapeString(file.Description))
+	}
+
+	if file.Title != "" {
+		attrs += fmt.Sprintf(` title="%s"`, html.EscapeString(file.Title))
+	}
+
+	return fmt.Sprintf("<img src=%q height=%d width=%d%s />", file.Filename, file.Height, file.Width, attrs)
+}
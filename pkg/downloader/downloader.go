@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"mime"
 	"net/http"
 	"os"
@@ -13,11 +13,18 @@ import (
 	"google.golang.org/api/docs/v1"
 )
 
-// ManifestFile is a file with size data.
+// ManifestFile is a file with size data. Height and Width are the size the
+// image is displayed at in the document, PixelHeight and PixelWidth the size
+// of the file after cropping and rotation. Title and Description are the
+// image's title and alt text in docs.
 type ManifestFile struct {
-	Filename string
-	Height   int64
-	Width    int64
+	Filename    string
+	Height      int64
+	Width       int64
+	PixelHeight int64
+	PixelWidth  int64
+	Title       string
+	Description string
 }
 
 // Manifest is just an object ID -> filename mapping.
@@ -47,7 +54,21 @@ func (a *Agent) Download(dir string, doc *docs.Document) error {
 	}
 
 	for id, obj := range doc.InlineObjects {
-		if err := a.fetch(obj, dir); err != nil {
+		if obj.InlineObjectProperties == nil {
+			continue
+		}
+
+		if err := a.fetch(id, obj.InlineObjectProperties.EmbeddedObject, dir); err != nil {
+			return fmt.Errorf("%q: %w", id, err)
+		}
+	}
+
+	for id, obj := range doc.PositionedObjects {
+		if obj.PositionedObjectProperties == nil {
+			continue
+		}
+
+		if err := a.fetch(id, obj.PositionedObjectProperties.EmbeddedObject, dir); err != nil {
 			return fmt.Errorf("%q: %w", id, err)
 		}
 	}
@@ -65,12 +86,14 @@ func (a *Agent) ManifestJSON() ([]byte, error) {
 	return json.Marshal(a.idFileMap)
 }
 
-func (a *Agent) fetch(obj docs.InlineObject, dir string) error {
-	if obj.InlineObjectProperties == nil || obj.InlineObjectProperties.EmbeddedObject == nil {
+func (a *Agent) fetch(id string, obj *docs.EmbeddedObject, dir string) error {
+	if obj == nil || obj.ImageProperties == nil {
 		return nil
 	}
 
-	resp, err := a.client.Get(obj.InlineObjectProperties.EmbeddedObject.ImageProperties.ContentUri)
+	props := obj.ImageProperties
+
+	resp, err := a.client.Get(props.ContentUri)
 	if err != nil {
 		return fmt.Errorf("while downloading: %w", err)
 	}
@@ -90,32 +113,39 @@ func (a *Agent) fetch(obj docs.InlineObject, dir string) error {
 		return fmt.Errorf("gathering extensions for content-type: %w", err)
 	}
 
-	fn := obj.ObjectId
+	fn := id
 	if len(exts) > 0 {
 		fn += exts[0]
 	}
 
 	fn = filepath.Join(dir, fn)
 
-	f, err := os.Create(fn)
+	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("could not create file %q: %w", fn, err)
+		return fmt.Errorf("while reading content: %w", err)
 	}
-	defer f.Close()
 
-	n, err := io.Copy(f, resp.Body)
+	if resp.ContentLength >= 0 && int64(len(data)) != resp.ContentLength {
+		return fmt.Errorf("Short read copying file")
+	}
+
+	data, width, height, err := processImage(data, props)
 	if err != nil {
-		return fmt.Errorf("while copying content: %w", err)
+		return fmt.Errorf("while processing image: %w", err)
 	}
 
-	if n != resp.ContentLength {
-		return fmt.Errorf("Short read copying file")
+	if err := ioutil.WriteFile(fn, data, 0666); err != nil {
+		return fmt.Errorf("could not create file %q: %w", fn, err)
 	}
 
-	a.idFileMap[obj.ObjectId] = ManifestFile{
-		Filename: fn,
-		Height:   int64(obj.InlineObjectProperties.EmbeddedObject.Size.Height.Magnitude),
-		Width:    int64(obj.InlineObjectProperties.EmbeddedObject.Size.Width.Magnitude),
+	a.idFileMap[id] = ManifestFile{
+		Filename:    fn,
+		Height:      int64(obj.Size.Height.Magnitude),
+		Width:       int64(obj.Size.Width.Magnitude),
+		PixelHeight: int64(height),
+		PixelWidth:  int64(width),
+		Title:       obj.Title,
+		Description: obj.Description,
 	}
 
 	return nil
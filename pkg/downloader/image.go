@@ -0,0 +1,131 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// processImage applies the crop and rotation docs displays the image with.
+// The image is returned in its original format along with its final size in
+// pixels. Images that need no changes, animated gifs, or images that can't be
+// decoded are returned as they are.
+func processImage(data []byte, props *docs.ImageProperties) ([]byte, int, int, error) {
+	crop := props.CropProperties
+	if crop == nil {
+		crop = &docs.CropProperties{}
+	}
+
+	if crop.OffsetLeft == 0 && crop.OffsetRight == 0 && crop.OffsetTop == 0 && crop.OffsetBottom == 0 && props.Angle == 0 {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return data, 0, 0, nil
+		}
+
+		return data, config.Width, config.Height, nil
+	}
+
+	// re-encoding an animation would keep only its first frame
+	if anim, err := gif.DecodeAll(bytes.NewReader(data)); err == nil && len(anim.Image) > 1 {
+		return data, anim.Config.Width, anim.Config.Height, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return data, 0, 0, nil
+	}
+
+	img = rotateImage(cropImage(img, crop), props.Angle)
+
+	var buf bytes.Buffer
+
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		// jpeg has no transparency for the corners of rotated images
+		bg := image.NewRGBA(img.Bounds())
+		draw.Draw(bg, bg.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(bg, bg.Bounds(), img, img.Bounds().Min, draw.Over)
+		err = jpeg.Encode(&buf, bg, &jpeg.Options{Quality: 90})
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return data, 0, 0, nil
+	}
+
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("while encoding %s: %w", format, err)
+	}
+
+	return buf.Bytes(), img.Bounds().Dx(), img.Bounds().Dy(), nil
+}
+
+// cropImage crops the image by the offsets, which are fractions of the
+// image's width and height.
+func cropImage(img image.Image, crop *docs.CropProperties) image.Image {
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+
+	rect := image.Rect(
+		b.Min.X+int(math.Round(crop.OffsetLeft*w)),
+		b.Min.Y+int(math.Round(crop.OffsetTop*h)),
+		b.Max.X-int(math.Round(crop.OffsetRight*w)),
+		b.Max.Y-int(math.Round(crop.OffsetBottom*h)),
+	)
+
+	if rect.Empty() || rect == b {
+		return img
+	}
+
+	res := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(res, res.Bounds(), img, rect.Min, draw.Src)
+
+	return res
+}
+
+// rotateImage rotates the image clockwise by angle radians. The result is
+// large enough to hold the whole rotated image, and transparent where the
+// image does not cover it.
+func rotateImage(img image.Image, angle float64) image.Image {
+	angle = math.Mod(angle, 2*math.Pi)
+	if math.Abs(angle) < 1e-9 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	sin, cos := math.Sincos(angle)
+
+	// rounding keeps right angles from growing a pixel
+	nw := int(math.Ceil(math.Round((math.Abs(w*cos)+math.Abs(h*sin))*1e6) / 1e6))
+	nh := int(math.Ceil(math.Round((math.Abs(w*sin)+math.Abs(h*cos))*1e6) / 1e6))
+
+	res := image.NewRGBA(image.Rect(0, 0, nw, nh))
+
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			// map the center of each pixel back onto the source image
+			dx := float64(x) + 0.5 - float64(nw)/2
+			dy := float64(y) + 0.5 - float64(nh)/2
+			sx := math.Floor(dx*cos + dy*sin + w/2)
+			sy := math.Floor(-dx*sin + dy*cos + h/2)
+
+			if sx < 0 || sy < 0 || sx >= w || sy >= h {
+				continue
+			}
+
+			res.Set(x, y, img.At(b.Min.X+int(sx), b.Min.Y+int(sy)))
+		}
+	}
+
+	return res
+}
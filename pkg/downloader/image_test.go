@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+func TestProcessImage(t *testing.T) {
+	// a 4x2 image, red on the left half and blue on the right
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name          string
+		props         *docs.ImageProperties
+		width, height int
+		// color of the top left pixel
+		topLeft color.RGBA
+	}{
+		{"untouched", &docs.ImageProperties{}, 4, 2, color.RGBA{R: 255, A: 255}},
+		{"cropped", &docs.ImageProperties{CropProperties: &docs.CropProperties{OffsetLeft: 0.5}}, 2, 2, color.RGBA{B: 255, A: 255}},
+		{"rotated", &docs.ImageProperties{Angle: math.Pi / 2}, 2, 4, color.RGBA{R: 255, A: 255}},
+		{"upside down", &docs.ImageProperties{Angle: math.Pi}, 4, 2, color.RGBA{B: 255, A: 255}},
+	}
+
+	for _, test := range table {
+		data, width, height, err := processImage(buf.Bytes(), test.props)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if width != test.width || height != test.height {
+			t.Fatalf("%s: expected %dx%d, got %dx%d", test.name, test.width, test.height, width, height)
+		}
+
+		res, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if res.Bounds().Dx() != width || res.Bounds().Dy() != height {
+			t.Fatalf("%s: image is %v, reported %dx%d", test.name, res.Bounds(), width, height)
+		}
+
+		if c := color.RGBAModel.Convert(res.At(0, 0)); c != test.topLeft {
+			t.Fatalf("%s: expected top left pixel %v, got %v", test.name, test.topLeft, c)
+		}
+	}
+}
+
+func TestProcessImageAnimated(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 4, 2), palette),
+			image.NewPaletted(image.Rect(0, 0, 4, 2), palette),
+		},
+		Delay: []int{10, 10},
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	props := &docs.ImageProperties{CropProperties: &docs.CropProperties{OffsetLeft: 0.5}, Angle: math.Pi / 2}
+
+	data, width, height, err := processImage(buf.Bytes(), props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatal("animated gif was re-encoded")
+	}
+
+	if width != 4 || height != 2 {
+		t.Fatalf("expected 4x2, got %dx%d", width, height)
+	}
+}
@@ -11,13 +11,19 @@ import (
 )
 
 // DownloadDoc just downloads the JSON representation of the document.
-func DownloadDoc(client *http.Client, docID string) (*docs.Document, error) {
+// suggestionsViewMode is passed on to docs if not empty.
+func DownloadDoc(client *http.Client, docID string, suggestionsViewMode string) (*docs.Document, error) {
 	srv, err := docs.New(client)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to retrieve Docs client: %v", err)
 	}
 
-	doc, err := srv.Documents.Get(docID).Do()
+	call := srv.Documents.Get(docID)
+	if suggestionsViewMode != "" {
+		call = call.SuggestionsViewMode(suggestionsViewMode)
+	}
+
+	doc, err := call.Do()
 	if err != nil {
 		return nil, fmt.Errorf("Unable to retrieve data from document: %v", err)
 	}
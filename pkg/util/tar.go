@@ -3,7 +3,6 @@ package util
 import (
 	"archive/tar"
 	"compress/gzip"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -16,9 +15,25 @@ import (
 	"github.com/erikh/gdocs-export/pkg/downloader"
 )
 
+// indexExtensions are the file extensions of formats not named after them.
+var indexExtensions = map[string]string{
+	"latex":      "tex",
+	"mediawiki":  "wiki",
+	"confluence": "xml",
+}
+
+// indexExtension returns the extension of the index file for format.
+func indexExtension(format string) string {
+	if ext, ok := indexExtensions[format]; ok {
+		return ext
+	}
+
+	return format
+}
+
 // MakeTarFromGDoc returns an opened file seeked to position 0. This file will
 // already contain a gzipped tarball that can be fed directly to a writer.
-func MakeTarFromGDoc(client *http.Client, url string, format string) (*os.File, error) {
+func MakeTarFromGDoc(client *http.Client, url string, format string, opts converters.Options) (*os.File, error) {
 	dir, err := ioutil.TempDir("", "gdocs-export-tar")
 	if err != nil {
 		return nil, err
@@ -30,7 +45,7 @@ func MakeTarFromGDoc(client *http.Client, url string, format string) (*os.File,
 		return nil, err
 	}
 
-	doc, err := DownloadDoc(client, docID)
+	doc, err := DownloadDoc(client, docID, opts.Suggestions.ViewMode())
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +62,7 @@ func MakeTarFromGDoc(client *http.Client, url string, format string) (*os.File,
 		manifestRW[key] = file
 	}
 
-	res, err := converters.Convert(format, doc, manifestRW)
+	res, err := converters.ConvertWithOptions(format, doc, manifestRW, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +104,7 @@ func MakeTarFromGDoc(client *http.Client, url string, format string) (*os.File,
 
 	indexHdr := &tar.Header{
 		Typeflag:   tar.TypeReg,
-		Name:       fmt.Sprintf("index.%s", format),
+		Name:       "index." + indexExtension(format),
 		Size:       int64(len([]byte(res))),
 		ModTime:    time.Now(),
 		AccessTime: time.Now(),
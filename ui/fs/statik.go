@@ -8,7 +8,7 @@ import (
 
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\xee^>R\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x010I\x15`\x9cTMo\xdb0\x0c\xbd\xe7Wp\xba/j\x0e\xbb\x0c\x8c/M\xb1\x0eh\xd1\xa0K\x0f;\xca6c\x0b\x91MC\xa2\xf3\xd1_?h\x8eS\xab\xeb:\xac\xa7\x88!\x1f\xf5\x1e\xa9g\xfc\xb4z\xb8\xde\xfc\\\xdf@-\x8d\xfb\x92\xcd0\xfef3\x00\xac\xc9\x94\xf1\x00\x80b\xc5Q\xf6\x8d\xb9r\x04+.\x02\\s\xbb'/\xe4Q\x0f\xc9\x88\xd0#\x04s.Ogl\xfc\x8f\xfc\x10\xc4p\x91U%\x17\xe13\x1d;\xf6\x02\x07\xca\xe1\xe9;\xeazq\xae\xd7S\x006\xc6\xb6\x17\xec\x96}\x03\x0dI\xcd\xe5R\xad\x1f~l\xd4\x98\x02\xc0\xd2\xee_\"\x00t&'\x07\xadih\xa9z\xefT\xf6\xf4x\x07\xbc\x85jPQr\x81\xfawM\x82\xb2m\xd7\x0b\xc8\xa9\xa3\xa5\x12:\x8a\x9at\x80`\x9fi\xa9\x16WW\n\xf4\xe4f\x9d\\\xfd.\x91\xa8\xc0\x88\xca\x1e)\xf4N`\x08\xdf\xe2\x11\xc8Q!\xafP\x93\x02\x00\xe4N,\xb7\xb07\xae\xa7\xa5\x8akS\xd9\xed\xe6\xfe\x0e\xf5\x90y\xb7\xbc)Uvo\xfc\xae\xe4C\xfb\x16\x00\xf5\xc0\xe0c2;O{K\x07\x95\xad\x87\xc3\xbf&]\xd4T\xecr>\xaaW\xf8\xff\x19\xf3\xb4_\xe8\xf3\xc6^vg\x8a\xa8N\x8d\xd2\xcfO7i>\xe2\x93\x91%\xbd\x92L\xd27\xc9\x9c\xefX\xf1\xa1ulJ0\xce\x81	\x81$\x80	0\x17\xe3\xe7\xd5\xf3\x14\xf27\x85\xa8\xe3\xd6GO\xbc\xd8\x00\xb7\xcc2\xf1S:\x14\x03\xb5\xa7m|\x0d\xd2\x85\xafZWV\xea>\x9f\x17\xdch\xf2vW\xeb\xa9\xf7T\xe2D\xd4&\x83\xfc\x047\xde\xee.\x0do\xd99jCN\xb3?(F\x82#\x13\xd4\x83\xe1Q\x0f_\x8f_\x01\x00\x00\xff\xffPK\x07\x08\x9cf\xad5\xb1\x01\x00\x00V\x04\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\xee^>R\x9cf\xad5\xb1\x01\x00\x00V\x04\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x010I\x15`PK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00\xf2\x01\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\xba\"R]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x01aI\xd4j\xac\x95_O\xdc0\x0c\xc0\xdf\xf9\x14^\xde!\xf0\xb0\x97)\x17	qhL\x02\x81\xe0\xd0\xb6\xc74\xf1\xb5\xd9\xa5I\x95\xb8\xf7\x87O?\xe5z\x7fZ\xc4\xa0\x9b\xf6\x14\xbb\xce\xcf\xb1\xeb\xc4\x16\x9f\xa6\xf7W\xb3\x9f\x0f\xd7PQ\xed>\xcb\x13\x91Wy\x02 *T&\x0b\x00\x82,9\x94_C(\x1d\xc24\xe8\x04W\xc1/1\x12F\xc1;c&\xf8\x1e\x11E0\x9b\x1d\x9b\xbfa\xec\x94\xec\xf5B\x96&\xe8t\x8a\xeb&D\x82\x15\x16\xf0\xfcM\xf0\xeab\xb7\x9f\xf7\x01Q+\xeb\x0f\xec<\xc4\x1aj\xa4*\x98	{\xb8\x7f\x9a\xb1\xbd	@\x18\xbb<j\x00\xc2\xa9\x02\x1dxU\xe3\x84\xb5\xd11\xf9\xfcx\x0ba\x0ee\x97\x85	Z\xf0\xed\x9e\x01e}\xd3\x12\xd0\xa6\xc1	#\\\x13\xeby\x80d_p\xc2.\xce\xcf\x19\xf0#%\xf8\xe0\xe8w\x03\xc9\x19(b\xf2\x11S\xeb\x08:\xf5\xad8\x12:\xd4\xf4\x8a\xeam\x00\x10\xa1!\x1b<,\x95kq\xc2r\xd9\x98\xbc\x99\xdd\xdd\n\xdeY\xde\xdd^\x1b&\xefT\\\x98\xb0\xf2\xa3\x00e\x82f\xf22ik\xa7A\x8fBb\"&#>Ql5\xb5\x11\xcd\x0c\xd74\xea0\xa7\x08\xd7L\xde\xaa\x19\xfe\x18\x05\x84X2y\x1f\xcb\xd3:\x18\x1cE\xd4h\xacZ\xd9\x85e\xf2.\x8b\xdf\xed\xc2\x8e\x02u\xf0s\xd7\xa2\xd7\xc8\xe4\xd5A~\x0b\x15\xbc\xab\xe2\xbf]\x95\xd4\x96%\xa6\\\xe2\xc4\xe4S\xa7\xa0\x01])_b\xfa\xf0\xce\x0c\xf0\xf7\xf2\xc9E\x05\xaap\x03*\"X\x9f\xe5\xfc:\xda\x1a\xfd\xb8j)\xad\xb1!&/\xb7+(\xe7Fa\x11\x7f\xa1\xde\xbe\x85\xbc\x8e\xc6j\x15\x17\xdd\xdd\x05\xeb\x13\xc6m\x8a\xa0\xbc\x01\x83\x0e\xb7\xda\xff/F\x13qiq\xc5\xe4C'|\xd4:t\x85zQ\x845{\xc5\xffM\xdf\xe8\xfbKmQ\xdbC3R:\xa7\xc9\xf6?r\xd7\x8b\x07\xce\xf7|\xcf!\x0c}\x0d,\x03\xbf\x03\xcb\xee\x8ciXy\x17\x94\xc9e\x02\x95\x12R\x02\x95\xe0\x8cT<+_\xfa\xc8\x9f2\x14<w\xbb}\x93?\xf6u1\x0f\x81z\x03b\xf8S\x14T\x11\xe7\xb9\xbdQ\x93\xbep^Z\xaa\xda\xe2L\x87\x9ac\xb4\x8b\x8a\xf7\x87	\x1b\x8c\x16\xc1\x95\x84b\x03\xd7\xd1.\x0e!\xdd\x04\xe7\xd0\xa7\x02\x0fQ\x1d\xce\x13\xfc\x18\x89\xe0\xdd\x04\x13\xbc\xa2\xda\xc9\x93\xdf\x03\x00PK\x07\x08x4n\x06W\x02\x00\x00'\x07\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\xba\"R]x4n\x06W\x02\x00\x00'\x07\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x01aI\xd4jPK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00\x98\x02\x00\x00\x00\x00"
 		fs.Register(data)
 	}
 	
\ No newline at end of file
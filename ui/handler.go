@@ -52,10 +52,18 @@ func convertURL(c echo.Context) error {
 	preview := params.Get("preview") == "on"
 	download := strings.HasPrefix(params.Get("action"), "Download")
 
+	suggestions, err := converters.ParseSuggestionMode(params.Get("suggestions"))
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+
+	opts := converters.Options{Suggestions: suggestions}
+
 	client := oauth2.GetClient()
 
 	if download {
-		tar, err := util.MakeTarFromGDoc(client, url, format)
+		tar, err := util.MakeTarFromGDoc(client, url, format, opts)
 		if err != nil {
 			c.Logger().Error(err)
 			return err
@@ -72,13 +80,13 @@ func convertURL(c echo.Context) error {
 		return err
 	}
 
-	doc, err := util.DownloadDoc(client, docID)
+	doc, err := util.DownloadDoc(client, docID, suggestions.ViewMode())
 	if err != nil {
 		c.Logger().Error(err)
 		return err
 	}
 
-	res, err := converters.Convert(format, doc, downloader.Manifest{})
+	res, err := converters.ConvertWithOptions(format, doc, downloader.Manifest{}, opts)
 	if err != nil {
 		c.Logger().Error(err)
 		return err
@@ -88,7 +96,7 @@ func convertURL(c echo.Context) error {
 	switch format {
 	case "html":
 		ct = "text/html"
-	case "md":
+	case "md", "adoc", "rst", "latex", "org", "mediawiki", "confluence":
 		ct = "text/plain"
 	default:
 		c.Logger().Error("invalid format")